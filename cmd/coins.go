@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/config"
+)
+
+// runCoins dispatches the coin discovery subcommands
+func runCoins(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return usagef("coins: want a subcommand: search <symbol or name>, list or refresh")
+	}
+
+	switch args[0] {
+	case "search":
+		return runCoinsSearch(ctx, cfg, args[1:])
+	case "list":
+		return runCoinsList(ctx, cfg, args[1:])
+	case "refresh":
+		return runCoinsRefresh(ctx, cfg, args[1:])
+	default:
+		return usagef("coins: unknown subcommand %q, want search, list or refresh", args[0])
+	}
+}
+
+// runCoinsSearch prints the CoinGecko ids a symbol or name may refer to
+func runCoinsSearch(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("coins search", flag.ContinueOnError)
+	limit := fs.Int("n", 10, "maximum number of candidates to show")
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return usagef("coins search: want a symbol or name, e.g. coins search DOT")
+	}
+	query := args[0]
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	candidates, err := a.client.ResolveCoin(ctx, query)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		fmt.Printf("No coins match %q\n", query)
+		return nil
+	}
+
+	fmt.Printf("%-32s %-10s %-28s %6s  %s\n", "ID", "SYMBOL", "NAME", "RANK", "MATCH")
+	for _, c := range candidates[:min(len(candidates), *limit)] {
+		rank, match := "-", "fuzzy"
+		if c.MarketCapRank > 0 {
+			rank = fmt.Sprint(c.MarketCapRank)
+		}
+		if c.Exact {
+			match = "exact"
+		}
+		fmt.Printf("%-32s %-10s %-28s %6s  %s\n", c.ID, strings.ToUpper(c.Symbol), c.Name, rank, match)
+	}
+	return nil
+}
+
+// runCoinsList prints the tracked coins
+func runCoinsList(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("coins list", flag.ContinueOnError)
+	all := fs.Bool("all", false, "include coins that dropped out of the top N")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	if cfg.Tracking.Mode != config.TrackTop {
+		fmt.Printf("%-32s %-10s %s\n", "ID", "SYMBOL", "NAME")
+		for _, c := range cfg.Coins {
+			fmt.Printf("%-32s %-10s %s\n", c.ID, c.Symbol, c.Name)
+		}
+		return nil
+	}
+
+	tracked, err := a.repo.GetTrackedCoins(*all)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-32s %-10s %-28s %6s  %-10s  %-10s  %s\n", "ID", "SYMBOL", "NAME", "RANK", "FIRST", "LAST", "STATUS")
+	for _, c := range tracked {
+		status := "active"
+		if !c.Active {
+			status = "dropped"
+		}
+		fmt.Printf("%-32s %-10s %-28s %6d  %-10s  %-10s  %s\n", c.ID, c.Symbol, c.Name, c.Rank,
+			c.FirstSeen.Format(time.DateOnly), c.LastSeen.Format(time.DateOnly), status)
+	}
+	return nil
+}
+
+// runCoinsRefresh updates the tracked top N now instead of waiting for the next fetch
+func runCoinsRefresh(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("coins refresh", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if cfg.Tracking.Mode != config.TrackTop {
+		return usagef("coins refresh: tracking.mode is %q; set it to %q to track the top coins", cfg.Tracking.Mode, config.TrackTop)
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	return a.refreshUniverse(ctx)
+}
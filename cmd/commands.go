@@ -0,0 +1,704 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/api"
+	"github.com/viczuno/go-crypto-bot/internal/config"
+	"github.com/viczuno/go-crypto-bot/internal/db"
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+	"github.com/viczuno/go-crypto-bot/internal/exporter"
+	"github.com/viczuno/go-crypto-bot/internal/markdown"
+	"github.com/viczuno/go-crypto-bot/internal/replay"
+	"github.com/viczuno/go-crypto-bot/internal/service"
+)
+
+// app bundles the dependencies shared by the subcommands
+type app struct {
+	cfg    *config.Config
+	client *api.CoinGeckoClient
+	repo   *db.SQLiteRepository
+	svc    *service.CryptoService
+	// clock is the system clock, or fixed at cfg.AsOf
+	clock domain.Clock
+}
+
+func newApp(cfg *config.Config) (*app, error) {
+	client := newCoinGeckoClient(cfg)
+	repo, err := db.NewSQLiteRepository(cfg.Paths.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	clock := newClock(cfg)
+	return &app{
+		cfg:    cfg,
+		client: client,
+		repo:   repo,
+		svc: service.NewCryptoService(newFetcher(cfg, client), repo, newReadmeBuilder(cfg, clock),
+			service.WithMissingPolicy(service.MissingPolicy(cfg.OnMissing)),
+			service.WithClock(clock),
+		),
+		clock: clock,
+	}, nil
+}
+
+// newClock returns the system clock, or one fixed at cfg.AsOf
+func newClock(cfg *config.Config) domain.Clock {
+	if !cfg.AsOf.IsZero() {
+		return domain.FixedClock(cfg.AsOf)
+	}
+	return domain.SystemClock
+}
+
+// newCoinGeckoClient creates the CoinGecko client with the configured plan and budget
+func newCoinGeckoClient(cfg *config.Config) *api.CoinGeckoClient {
+	opts := append(clientOptions(cfg),
+		api.WithCurrencies(cfg.Currencies...),
+		api.WithAPIKey(cfg.CoinGecko.Tier, string(cfg.CoinGecko.APIKey)),
+		api.WithBaseURL(cfg.CoinGecko.BaseURL),
+		api.WithChunking(cfg.CoinGecko.ChunkSize, cfg.CoinGecko.Concurrency),
+	)
+	// Replayed responses cost nothing, so they are not held to the request budget
+	if cfg.Fixtures.Replay == "" {
+		opts = append(opts, api.WithRateLimiter(api.NewRateLimiter(coinGeckoBudget(cfg))))
+	}
+	return api.NewCoinGeckoClient(opts...)
+}
+
+// clientOptions returns the transport settings shared by all API clients
+func clientOptions(cfg *config.Config) []api.Option {
+	retry := api.RetryPolicy{
+		MaxAttempts: cfg.Retry.MaxAttempts,
+		BaseDelay:   cfg.Retry.BaseDelay.Std(),
+		MaxDelay:    cfg.Retry.MaxDelay.Std(),
+	}
+	opts := []api.Option{
+		api.WithTimeout(cfg.Timeout.Request.Std()),
+		api.WithClock(newClock(cfg)),
+	}
+
+	switch {
+	case cfg.Fixtures.Replay != "":
+		// A replayed failure would fail the same way again
+		retry.MaxAttempts = 1
+		opts = append(opts, api.WithTransport(replay.NewReplayer(cfg.Fixtures.Replay)))
+	case cfg.Fixtures.Record != "":
+		opts = append(opts, api.WithTransport(replay.NewRecorder(cfg.Fixtures.Record, nil)))
+	}
+	return append(opts, api.WithRetry(retry))
+}
+
+// newFetcher combines the configured providers: by default each coin comes from the
+// first one that has it, in median mode from the median of all of them
+func newFetcher(cfg *config.Config, coinGecko *api.CoinGeckoClient) domain.PriceFetcher {
+	providers := make([]api.Provider, 0, len(cfg.Providers))
+	for _, name := range cfg.Providers {
+		switch name {
+		case api.ProviderCoinGecko:
+			providers = append(providers, api.Provider{Name: name, Fetcher: coinGecko})
+		case api.ProviderBinance:
+			binance := api.NewBinanceClient(cfg.Binance.Symbols, append(clientOptions(cfg),
+				api.WithBaseURL(cfg.Binance.BaseURL),
+			)...)
+			providers = append(providers, api.Provider{Name: name, Fetcher: binance})
+		case api.ProviderKraken:
+			kraken := api.NewKrakenClient(cfg.Kraken.Symbols, append(clientOptions(cfg),
+				api.WithBaseURL(cfg.Kraken.BaseURL),
+			)...)
+			providers = append(providers, api.Provider{Name: name, Fetcher: kraken})
+		case api.ProviderCoinbase:
+			coinbase := api.NewCoinbaseClient(cfg.Coinbase.Symbols, append(clientOptions(cfg),
+				api.WithBaseURL(cfg.Coinbase.BaseURL),
+			)...)
+			providers = append(providers, api.Provider{Name: name, Fetcher: coinbase})
+		}
+	}
+	if cfg.Aggregation.Mode == config.AggregateMedian {
+		return api.NewMedianFetcher(cfg.Aggregation.TolerancePct, cfg.Aggregation.Quorum, providers...)
+	}
+	return api.NewFallbackFetcher(providers...)
+}
+
+func newReadmeBuilder(cfg *config.Config, clock domain.Clock) *markdown.ReadmeBuilder {
+	opts := []markdown.Option{
+		markdown.WithClock(clock),
+		markdown.WithCurrencies(cfg.Report.Currencies...),
+		markdown.WithStaleAfter(cfg.Report.StaleAfter.Std()),
+		markdown.WithMaxSpread(cfg.Report.MaxSpreadPct),
+	}
+	if cfg.Report.SortBy == config.SortByMarketCap {
+		opts = append(opts, markdown.WithMarketCapOrder())
+	}
+	return markdown.NewReadmeBuilder(opts...)
+}
+
+// coinGeckoBudget returns the configured CoinGecko requests per minute, defaulting to the plan's limit
+func coinGeckoBudget(cfg *config.Config) int {
+	if cfg.CoinGecko.RequestsPerMinute > 0 {
+		return cfg.CoinGecko.RequestsPerMinute
+	}
+	return api.DefaultRequestsPerMinute(cfg.CoinGecko.Tier)
+}
+
+func (a *app) Close() error {
+	a.logUsage()
+	return a.repo.Close()
+}
+
+// logUsage reports the CoinGecko requests sent and how much the rate limiter delayed them
+func (a *app) logUsage() {
+	u := a.client.Usage()
+	if u.Requests == 0 {
+		return
+	}
+	log.Printf("CoinGecko usage (%s tier, %d/min budget): %d requests, %d retries, %d throttled for %s",
+		a.cfg.CoinGecko.Tier, coinGeckoBudget(a.cfg), u.Requests, u.Retries, u.Throttled, u.Waited.Round(time.Millisecond))
+}
+
+func (a *app) writeReadme(content string) error {
+	return os.WriteFile(a.cfg.Paths.Readme, []byte(content), 0644)
+}
+
+func (a *app) exportHugo(stats []domain.CoinStats, coins []domain.CoinMetadata) error {
+	hugo := a.hugoExporter()
+	if err := hugo.ExportAll(stats, coins, a.repo, a.cfg.History.Days); err != nil {
+		return err
+	}
+	return hugo.ExportCandles(coins, a.repo, a.cfg.History.CandleDays)
+}
+
+func (a *app) hugoExporter() *exporter.HugoExporter {
+	return exporter.NewHugoExporter(a.cfg.Paths.HugoData, a.cfg.Paths.HugoHistory,
+		exporter.WithCandlesPath(a.cfg.Paths.HugoCandles),
+		exporter.WithClock(a.clock),
+		exporter.WithResolution(a.cfg.History.ExportResolution()),
+	)
+}
+
+// coins returns the coins to work on: the configured list or, in top-N mode, the
+// tracked universe, which is first refreshed from CoinGecko when refresh is set
+func (a *app) coins(ctx context.Context, refresh bool) ([]domain.CoinMetadata, error) {
+	if a.cfg.Tracking.Mode != config.TrackTop {
+		return a.cfg.Coins, nil
+	}
+	if refresh {
+		if err := a.refreshUniverse(ctx); err != nil {
+			log.Printf("Warning: failed to refresh the top %d coins, using the stored list: %v", a.cfg.Tracking.TopN, err)
+		}
+	}
+
+	tracked, err := a.repo.GetTrackedCoins(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(tracked) == 0 {
+		return nil, errors.New("no tracked coins yet; run fetch or coins refresh first")
+	}
+	coins := make([]domain.CoinMetadata, len(tracked))
+	for i, c := range tracked {
+		coins[i] = c.CoinMetadata
+	}
+	return coins, nil
+}
+
+// refreshUniverse replaces the tracked coins with the current top N by market cap
+func (a *app) refreshUniverse(ctx context.Context) error {
+	top, err := a.client.TopCoins(ctx, a.cfg.Tracking.TopN)
+	if err != nil {
+		return err
+	}
+	coins := make([]domain.CoinMetadata, len(top))
+	for i, c := range top {
+		coins[i] = c.Metadata()
+	}
+
+	added, dropped, err := a.repo.SyncTrackedCoins(coins, a.clock.Now().UTC())
+	if err != nil {
+		return err
+	}
+	log.Printf("Tracking the top %d coins by market cap", len(coins))
+	if len(added) > 0 {
+		log.Printf("Joined the top %d: %s", a.cfg.Tracking.TopN, strings.Join(added, ", "))
+	}
+	if len(dropped) > 0 {
+		log.Printf("Dropped out of the top %d (history kept): %s", a.cfg.Tracking.TopN, strings.Join(dropped, ", "))
+	}
+	return nil
+}
+
+// pipeline fetches and stores prices, then writes the README and Hugo data
+func (a *app) pipeline(ctx context.Context) error {
+	coins, err := a.coins(ctx, true)
+	if err != nil {
+		return err
+	}
+	content, stats, err := a.svc.UpdateAndGenerateReport(ctx, coins)
+	if err != nil {
+		return err
+	}
+	if err := a.writeReadme(content); err != nil {
+		return err
+	}
+	return a.exportHugo(stats, coins)
+}
+
+func (a *app) fetch(ctx context.Context) error {
+	coins, err := a.coins(ctx, true)
+	if err != nil {
+		return err
+	}
+	_, err = a.svc.UpdatePrices(ctx, coins)
+	return err
+}
+
+func (a *app) report(ctx context.Context) error {
+	coins, err := a.coins(ctx, false)
+	if err != nil {
+		return err
+	}
+	content, _, err := a.svc.ReportFromHistory(coins)
+	if err != nil {
+		return err
+	}
+	return a.writeReadme(content)
+}
+
+func (a *app) export(ctx context.Context) error {
+	coins, err := a.coins(ctx, false)
+	if err != nil {
+		return err
+	}
+	_, stats, err := a.svc.ReportFromHistory(coins)
+	if err != nil {
+		return err
+	}
+	return a.exportHugo(stats, coins)
+}
+
+// candles fetches and stores the OHLC candles of every coin, then exports the candle files
+func (a *app) candles(ctx context.Context) error {
+	coins, err := a.coins(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, coin := range coins {
+		candles, err := a.client.FetchOHLC(ctx, coin.ID, a.cfg.History.CandleDays)
+		if err == nil {
+			err = a.repo.SaveCandles(candles)
+		}
+		if err != nil {
+			log.Printf("Error: failed to update candles for %s: %v", coin.ID, err)
+			failed = append(failed, coin.ID)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		log.Printf("Stored %d candles for %s", len(candles), coin.ID)
+	}
+
+	if err := a.hugoExporter().ExportCandles(coins, a.repo, a.cfg.History.CandleDays); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("candles failed for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if fs.NArg() > 0 {
+		return usagef("%s: unexpected arguments: %s", fs.Name(), strings.Join(fs.Args(), " "))
+	}
+	return nil
+}
+
+// runAll fetches, reports and exports in one go, like the original single pipeline
+func runAll(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	return a.pipeline(ctx)
+}
+
+func runFetch(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	return a.fetch(ctx)
+}
+
+func runReport(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	output := fs.String("o", cfg.Paths.Readme, "README output path, or - for stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	if *output != "-" {
+		cfg.Paths.Readme = *output
+		return a.report(ctx)
+	}
+
+	coins, err := a.coins(ctx, false)
+	if err != nil {
+		return err
+	}
+	content, _, err := a.svc.ReportFromHistory(coins)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(os.Stdout, content)
+	return err
+}
+
+func runExport(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	days := fs.Int("days", cfg.History.Days, "days of history to export per coin")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *days <= 0 {
+		return usagef("export: -days must be positive")
+	}
+	cfg.History.Days = *days
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	return a.export(ctx)
+}
+
+func runBackfill(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	days := fs.Int("days", cfg.History.Days, "days of history to load, ending at -to (ignored when -from is set)")
+	fromStr := fs.String("from", "", "start date (YYYY-MM-DD or RFC 3339)")
+	toStr := fs.String("to", "", "end date (YYYY-MM-DD or RFC 3339, default now)")
+	intervalStr := fs.String("interval", "daily", "sample interval: daily or hourly")
+	coinList := fs.String("coins", "", "comma-separated coin ids (default all tracked coins)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var interval time.Duration
+	switch *intervalStr {
+	case "daily":
+		interval = 24 * time.Hour
+	case "hourly":
+		interval = time.Hour
+	default:
+		return usagef("backfill: -interval must be daily or hourly, got %q", *intervalStr)
+	}
+
+	to := newClock(cfg).Now().UTC()
+	if *toStr != "" {
+		t, err := parseDate(*toStr)
+		if err != nil {
+			return usagef("backfill: -to: %v", err)
+		}
+		to = t
+	}
+
+	var from time.Time
+	switch {
+	case *fromStr != "":
+		t, err := parseDate(*fromStr)
+		if err != nil {
+			return usagef("backfill: -from: %v", err)
+		}
+		from = t
+	case *days > 0:
+		from = to.AddDate(0, 0, -*days)
+	default:
+		return usagef("backfill: -days must be positive")
+	}
+	if !from.Before(to) {
+		return usagef("backfill: -from must be before -to")
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	tracked, err := a.coins(ctx, false)
+	if err != nil {
+		return err
+	}
+	coins, err := selectCoins(tracked, *coinList)
+	if err != nil {
+		return err
+	}
+
+	backfiller := service.NewBackfiller(a.client, a.repo)
+	var results []service.BackfillResult
+	var failed []string
+	for _, coin := range coins {
+		result, err := backfiller.Backfill(ctx, coin.ID, from, to, interval)
+		results = append(results, result)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			failed = append(failed, coin.ID)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+
+	fmt.Printf("%-16s %8s %9s %8s\n", "COIN", "FETCHED", "INSERTED", "SKIPPED")
+	for _, r := range results {
+		fmt.Printf("%-16s %8d %9d %8d\n", r.Coin, r.Fetched, r.Inserted, r.Skipped)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("backfill failed for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func runCandles(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("candles", flag.ContinueOnError)
+	days := fs.Int("days", cfg.History.CandleDays, fmt.Sprintf("range of candles to fetch, one of %v", api.OHLCDays))
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if !slices.Contains(api.OHLCDays, *days) {
+		return usagef("candles: -days must be one of %v, got %d", api.OHLCDays, *days)
+	}
+	cfg.History.CandleDays = *days
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	return a.candles(ctx)
+}
+
+func runStats(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	coins, err := a.coins(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-16s %8s  %-20s  %-20s  %s\n", "COIN", "SAMPLES", "FIRST", "LAST", "DAYS")
+	for _, coin := range coins {
+		summary, err := a.repo.GetCoinSummary(coin.ID)
+		if err != nil {
+			return err
+		}
+		if summary.Samples == 0 {
+			fmt.Printf("%-16s %8d  %-20s  %-20s  %d\n", coin.ID, 0, "-", "-", 0)
+			continue
+		}
+		span := int(summary.Last.Sub(summary.First).Hours() / 24)
+		fmt.Printf("%-16s %8d  %-20s  %-20s  %d\n", coin.ID, summary.Samples,
+			summary.First.Format(time.DateTime), summary.Last.Format(time.DateTime), span)
+	}
+	return nil
+}
+
+func runDoctor(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	offline := fs.Bool("offline", false, "skip the API reachability check")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	failed := false
+	check := func(name string, err error) {
+		if err != nil {
+			failed = true
+			fmt.Printf("FAIL  %s: %v\n", name, err)
+			return
+		}
+		fmt.Printf("ok    %s\n", name)
+	}
+
+	check(fmt.Sprintf("config (%d coins)", len(cfg.Coins)), cfg.Validate())
+
+	// The database is inspected as it is; doctor neither creates nor migrates it
+	if _, err := os.Stat(cfg.Paths.DB); errors.Is(err, os.ErrNotExist) {
+		check("database "+cfg.Paths.DB+" (not created yet)", checkWritableDir(filepath.Dir(cfg.Paths.DB)))
+	} else {
+		repo, err := db.OpenSQLiteRepository(cfg.Paths.DB)
+		check("database "+cfg.Paths.DB, err)
+		if err == nil {
+			check("database integrity", repo.Check())
+			pending, err := pendingMigrations(repo)
+			check("database schema", err)
+			if len(pending) > 0 {
+				fmt.Printf("warn  %d pending migrations (%s); the next run applies them, or run migrate up\n",
+					len(pending), strings.Join(pending, ", "))
+			}
+			check("quarantined rows", checkQuarantine(repo))
+			_ = repo.Close()
+		}
+	}
+
+	check("readme path "+cfg.Paths.Readme, checkWritableDir(filepath.Dir(cfg.Paths.Readme)))
+	check("hugo data path "+cfg.Paths.HugoData, checkCreatableDir(filepath.Dir(cfg.Paths.HugoData)))
+	check("hugo history path "+cfg.Paths.HugoHistory, checkCreatableDir(cfg.Paths.HugoHistory))
+	check("hugo candles path "+cfg.Paths.HugoCandles, checkCreatableDir(cfg.Paths.HugoCandles))
+
+	if !*offline {
+		check("coingecko api", newCoinGeckoClient(cfg).Ping(ctx))
+	}
+
+	if failed {
+		return errUnhealthy
+	}
+	return nil
+}
+
+// selectCoins filters the tracked coins by a comma-separated id list
+func selectCoins(coins []domain.CoinMetadata, list string) ([]domain.CoinMetadata, error) {
+	if list == "" {
+		return coins, nil
+	}
+
+	byID := make(map[string]domain.CoinMetadata, len(coins))
+	for _, c := range coins {
+		byID[c.ID] = c
+	}
+
+	var selected []domain.CoinMetadata
+	for _, id := range strings.Split(list, ",") {
+		id = strings.TrimSpace(id)
+		coin, ok := byID[id]
+		if !ok {
+			return nil, usagef("unknown coin %q (not tracked)", id)
+		}
+		selected = append(selected, coin)
+	}
+	return selected, nil
+}
+
+// parseDate accepts a plain UTC date or a full RFC 3339 timestamp
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD or RFC 3339", s)
+	}
+	return t.UTC(), nil
+}
+
+// checkQuarantine fails if migrations set aside rows they could not convert
+func checkQuarantine(repo *db.SQLiteRepository) error {
+	n, err := repo.QuarantineCount()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("%d rows could not be migrated; see the quarantine table", n)
+	}
+	return nil
+}
+
+// pendingMigrations lists the migrations the database lacks. Migrations it has
+// that this build does not know are an error, as no command can use it.
+func pendingMigrations(repo *db.SQLiteRepository) ([]string, error) {
+	status, err := repo.MigrationStatus()
+	if err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, s := range status {
+		switch {
+		case s.Unknown:
+			return nil, fmt.Errorf("database has migration %s, which this build does not know", s.Migration)
+		case !s.Applied:
+			pending = append(pending, s.Migration.String())
+		}
+	}
+	return pending, nil
+}
+
+// checkWritableDir verifies that files can be created in the existing directory dir
+func checkWritableDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkCreatableDir verifies that dir is writable or, for the export paths the
+// bot creates, that its nearest existing parent is. Nothing is created.
+func checkCreatableDir(dir string) error {
+	for {
+		_, err := os.Stat(dir)
+		parent := filepath.Dir(dir)
+		if !errors.Is(err, os.ErrNotExist) || parent == dir {
+			return checkWritableDir(dir)
+		}
+		dir = parent
+	}
+}
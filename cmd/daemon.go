@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/viczuno/go-crypto-bot/internal/config"
+	"github.com/viczuno/go-crypto-bot/internal/scheduler"
+)
+
+// runDaemon keeps the service running and triggers pipeline stages on the configured schedule
+func runDaemon(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	statePath := fs.String("state", cfg.Daemon.StatePath, "file recording last runs, used to catch up after downtime")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if len(cfg.Daemon.Jobs) == 0 {
+		return usagef("daemon: no jobs configured in daemon.jobs")
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	stages := map[string]func(context.Context) error{
+		"run":     a.pipeline,
+		"fetch":   a.fetch,
+		"report":  a.report,
+		"export":  a.export,
+		"candles": a.candles,
+		"compact": func(ctx context.Context) error { return a.compact(ctx, false) },
+	}
+
+	sched := scheduler.New(*statePath)
+	for _, job := range cfg.Daemon.Jobs {
+		schedule, err := scheduler.Parse(job.Schedule)
+		if err != nil {
+			return fmt.Errorf("daemon job %s: %w", job.Name, err)
+		}
+		sched.Add(scheduler.Job{
+			Name:     job.Name,
+			Schedule: schedule,
+			Jitter:   job.Jitter.Std(),
+			Timeout:  cfg.Timeout.Run.Std(),
+			Run:      stages[job.Name],
+		})
+	}
+
+	return sched.Run(ctx)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/config"
+)
+
+// runDB dispatches the database maintenance subcommands
+func runDB(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return usagef("db: want a subcommand: compact")
+	}
+	if args[0] != "compact" {
+		return usagef("db: unknown subcommand %q, want compact", args[0])
+	}
+
+	fs := flag.NewFlagSet("db compact", flag.ContinueOnError)
+	ifPruned := fs.Bool("if-pruned", false, "vacuum only when retention deleted rows, leaving the file untouched otherwise")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+
+	a, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = a.Close() }()
+
+	return a.compact(ctx, *ifPruned)
+}
+
+// compact deletes the history older than the retention policy allows and vacuums
+// the database, logging its size before and after. With ifPruned it skips the
+// vacuum when nothing was deleted.
+func (a *app) compact(ctx context.Context, ifPruned bool) error {
+	before, err := a.repo.Size()
+	if err != nil {
+		return err
+	}
+
+	now := a.clock.Now()
+	var rawBefore, rollupsBefore time.Time
+	if days := a.cfg.Retention.RawDays; days > 0 {
+		rawBefore = now.AddDate(0, 0, -days)
+	}
+	if days := a.cfg.Retention.RollupDays; days > 0 {
+		rollupsBefore = now.AddDate(0, 0, -days)
+	}
+	pruned, err := a.repo.Prune(rawBefore, rollupsBefore)
+	if err != nil {
+		return err
+	}
+	log.Printf("Pruned %d price samples, %d quotes and %d rollups", pruned.Prices, pruned.Quotes, pruned.Rollups)
+	if ifPruned && pruned.Prices+pruned.Quotes+pruned.Rollups == 0 {
+		log.Printf("Nothing pruned, database left at %s", formatSize(before))
+		return nil
+	}
+
+	if err := a.repo.Vacuum(); err != nil {
+		return err
+	}
+	after, err := a.repo.Size()
+	if err != nil {
+		return err
+	}
+	log.Printf("Compacted database from %s to %s", formatSize(before), formatSize(after))
+	return nil
+}
+
+// formatSize renders a byte count in KiB or MiB
+func formatSize(n int64) string {
+	if n < 1<<20 {
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+}
@@ -2,66 +2,181 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/viczuno/go-crypto-bot/internal/api"
-	"github.com/viczuno/go-crypto-bot/internal/db"
-	"github.com/viczuno/go-crypto-bot/internal/domain"
-	"github.com/viczuno/go-crypto-bot/internal/exporter"
-	"github.com/viczuno/go-crypto-bot/internal/markdown"
-	"github.com/viczuno/go-crypto-bot/internal/service"
+	"github.com/viczuno/go-crypto-bot/internal/config"
 )
 
+// Exit codes returned by the CLI
 const (
-	dbPath          = "./crypto_history.db"
-	readmePath      = "./README.md"
-	hugoDataPath    = "./data/crypto.json"
-	hugoHistoryPath = "./data/history"
-	timeout         = 5 * time.Minute
-	historyDays     = 30
+	exitOK        = 0
+	exitFailure   = 1
+	exitUsage     = 2
+	exitUnhealthy = 3
 )
 
+// command is a single CLI subcommand
+type command struct {
+	name    string
+	summary string
+	// longRunning commands are not bound by the run timeout
+	longRunning bool
+	// historical commands work from stored prices only and accept --as-of
+	historical bool
+	run        func(ctx context.Context, cfg *config.Config, args []string) error
+}
+
+var commands = []command{
+	{name: "run", summary: "fetch prices, write the README and export Hugo data (default)", run: runAll},
+	{name: "fetch", summary: "fetch current prices and store them in the database", run: runFetch},
+	{name: "report", summary: "write the README from stored prices without calling the API", historical: true, run: runReport},
+	{name: "export", summary: "export Hugo data from stored prices without calling the API", historical: true, run: runExport},
+	{name: "backfill", summary: "load historical prices from CoinGecko into the database", run: runBackfill},
+	{name: "candles", summary: "fetch OHLC candles from CoinGecko, store them and export the candle files", run: runCandles},
+	{name: "stats", summary: "print stored history per coin", run: runStats},
+	{name: "coins", summary: "search CoinGecko ids (coins search DOT), list or refresh the tracked coins", run: runCoins},
+	{name: "rollups", summary: "regenerate the hourly, daily and weekly price aggregates: rollups rebuild", run: runRollups},
+	{name: "db", summary: "prune history past the retention policy and vacuum the database: db compact", run: runDB},
+	{name: "migrate", summary: "show or change the database schema version: migrate status, up or down", run: runMigrate},
+	{name: "doctor", summary: "check config, database, output paths and API reachability", run: runDoctor},
+	{name: "daemon", summary: "keep running and execute the scheduled jobs from daemon.jobs", longRunning: true, run: runDaemon},
+}
+
+// usageError marks errors caused by invalid arguments
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// errUnhealthy marks a doctor run with failed checks
+var errUnhealthy = errors.New("one or more checks failed")
+
 func main() {
-	log.Println("Starting Go-Crypto-Bot...")
+	os.Exit(realMain(os.Args[1:]))
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+func realMain(args []string) int {
+	global := flag.NewFlagSet("crypto-bot", flag.ContinueOnError)
+	configPath := global.String("config", "", "path to the JSON config file (default $"+config.EnvConfigPath+" or "+config.DefaultPath+")")
+	record := global.String("record", "", "save every API response as a fixture in this directory")
+	replay := global.String("replay", "", "answer API requests from the fixtures in this directory instead of the network")
+	asOf := global.String("as-of", "", "rebuild report or export as of this moment (YYYY-MM-DD or RFC 3339) from stored history")
+	global.Usage = func() { printUsage(global) }
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
 
-	go handleShutdown(cancel)
+	name := "run"
+	rest := global.Args()
+	if len(rest) > 0 {
+		name, rest = rest[0], rest[1:]
+	}
 
-	if err := run(ctx); err != nil {
-		log.Fatalf("Error: %v", err)
+	cmd, ok := findCommand(name)
+	if !ok {
+		log.Printf("Error: unknown command %q", name)
+		printUsage(global)
+		return exitUsage
 	}
 
-	log.Println("Successfully completed all tasks")
-}
+	log.Println("Starting Go-Crypto-Bot...")
 
-func run(ctx context.Context) error {
-	fetcher := api.NewCoinGeckoClient()
-	repo, err := db.NewSQLiteRepository(dbPath)
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		return err
+		log.Printf("Error: %v", err)
+		return exitUsage
+	}
+	for _, w := range cfg.Warnings() {
+		log.Printf("Warning: config: %s", w)
+	}
+	if *asOf != "" {
+		if !cmd.historical {
+			log.Printf("Error: -as-of only applies to commands that work from stored prices, not %q", cmd.name)
+			return exitUsage
+		}
+		t, err := parseDate(*asOf)
+		if err != nil {
+			log.Printf("Error: -as-of: %v", err)
+			return exitUsage
+		}
+		cfg.AsOf = t
+		log.Printf("Rebuilding as of %s", t.Format(time.RFC3339))
+	}
+	if *record != "" || *replay != "" {
+		cfg.Fixtures = config.Fixtures{Record: *record, Replay: *replay}
+		if err := cfg.Validate(); err != nil {
+			log.Printf("Error: %v", err)
+			return exitUsage
+		}
+	}
+
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if cmd.longRunning {
+		ctx, cancel = context.WithCancel(context.Background())
+	} else {
+		ctx, cancel = context.WithTimeout(context.Background(), cfg.Timeout.Run.Std())
 	}
-	defer func() { _ = repo.Close() }()
+	defer cancel()
 
-	coins := domain.DefaultCoins()
+	go handleShutdown(cancel)
 
-	svc := service.NewCryptoService(fetcher, repo, markdown.NewReadmeBuilder())
-	content, stats, err := svc.UpdateAndGenerateReport(ctx, coins)
-	if err != nil {
-		return err
+	if err := cmd.run(ctx, cfg, rest); err != nil {
+		var usageErr *usageError
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.As(err, &usageErr):
+			log.Printf("Error: %v", err)
+			return exitUsage
+		case errors.Is(err, errUnhealthy):
+			log.Printf("Error: %v", err)
+			return exitUnhealthy
+		default:
+			log.Printf("Error: %v", err)
+			return exitFailure
+		}
 	}
 
-	if err := os.WriteFile(readmePath, []byte(content), 0644); err != nil {
-		return err
+	log.Println("Successfully completed all tasks")
+	return exitOK
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
 	}
+	return command{}, false
+}
 
-	hugo := exporter.NewHugoExporter(hugoDataPath, hugoHistoryPath)
-	return hugo.ExportAll(stats, coins, repo, historyDays)
+func printUsage(global *flag.FlagSet) {
+	out := global.Output()
+	_, _ = fmt.Fprintf(out, "Usage: crypto-bot [-config path] <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		_, _ = fmt.Fprintf(out, "  %-10s %s\n", c.name, c.summary)
+	}
+	_, _ = fmt.Fprintf(out, "\nGlobal flags:\n")
+	global.PrintDefaults()
+	_, _ = fmt.Fprintf(out, "\nExit codes: 0 success, 1 failure, 2 usage or config error, 3 doctor checks failed\n")
 }
 
 func handleShutdown(cancel context.CancelFunc) {
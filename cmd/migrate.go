@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/config"
+	"github.com/viczuno/go-crypto-bot/internal/db"
+)
+
+// runMigrate dispatches the schema migration subcommands. It opens the database
+// without the automatic forward migration every other command runs.
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return usagef("migrate: want a subcommand: status, up or down")
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	var steps *int
+	var force *bool
+	switch args[0] {
+	case "status":
+	case "up":
+		steps = fs.Int("n", 0, "number of migrations to apply (default all)")
+	case "down":
+		steps = fs.Int("n", 1, "number of migrations to roll back")
+		force = fs.Bool("force", false, "allow rolling back the initial migration, which drops all stored data")
+	default:
+		return usagef("migrate: unknown subcommand %q, want status, up or down", args[0])
+	}
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+
+	repo, err := db.OpenSQLiteRepository(cfg.Paths.DB)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = repo.Close() }()
+
+	switch args[0] {
+	case "up":
+		applied, err := repo.MigrateUp(*steps)
+		for _, m := range applied {
+			fmt.Printf("applied %s\n", m)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return err
+	case "down":
+		if *steps <= 0 {
+			return usagef("migrate down: -n must be positive")
+		}
+		if !*force {
+			if err := checkKeepsInitial(repo, *steps); err != nil {
+				return err
+			}
+		}
+		rolledBack, err := repo.MigrateDown(*steps)
+		for _, m := range rolledBack {
+			fmt.Printf("rolled back %s\n", m)
+		}
+		return err
+	}
+
+	status, err := repo.MigrationStatus()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-40s %-8s %s\n", "MIGRATION", "STATE", "APPLIED AT")
+	for _, s := range status {
+		state, appliedAt := "pending", "-"
+		if s.Applied {
+			state = "applied"
+			appliedAt = s.AppliedAt.Format(time.DateTime)
+		}
+		if s.Unknown {
+			state = "unknown"
+		}
+		fmt.Printf("%-40s %-8s %s\n", s.Migration, state, appliedAt)
+	}
+
+	legacy, err := repo.LegacySchema()
+	if err != nil {
+		return err
+	}
+	if legacy {
+		fmt.Println("untracked legacy schema: migrate up adopts it")
+	}
+	return nil
+}
+
+// checkKeepsInitial refuses a rollback that would undo the first migration
+func checkKeepsInitial(repo *db.SQLiteRepository, steps int) error {
+	status, err := repo.MigrationStatus()
+	if err != nil {
+		return err
+	}
+	applied := 0
+	for _, s := range status {
+		if s.Applied {
+			applied++
+		}
+	}
+	if steps >= applied && applied > 0 {
+		return usagef("migrate down: rolling back %d migrations would undo %s and drop all stored data; pass -force to do it anyway", steps, status[0].Migration)
+	}
+	return nil
+}
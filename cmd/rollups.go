@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/viczuno/go-crypto-bot/internal/config"
+	"github.com/viczuno/go-crypto-bot/internal/db"
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// runRollups dispatches the rollup maintenance subcommands
+func runRollups(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return usagef("rollups: want a subcommand: rebuild")
+	}
+	if args[0] != "rebuild" {
+		return usagef("rollups: unknown subcommand %q, want rebuild", args[0])
+	}
+
+	fs := flag.NewFlagSet("rollups rebuild", flag.ContinueOnError)
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+
+	repo, err := db.NewSQLiteRepository(cfg.Paths.DB)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = repo.Close() }()
+
+	counts, err := repo.RebuildRollups()
+	if err != nil {
+		return err
+	}
+	for _, res := range domain.RollupResolutions {
+		fmt.Printf("%-4s %d rollups\n", res, counts[res])
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+const binanceBaseURL = "https://api.binance.com"
+
+// ProviderBinance is the source name recorded for prices from Binance
+const ProviderBinance = "binance"
+
+// Ensure BinanceClient implements PriceFetcher
+var _ domain.PriceFetcher = (*BinanceClient)(nil)
+
+// BinanceClient implements domain.PriceFetcher using the Binance spot 24hr ticker.
+// Prices of USDT pairs are reported as USD.
+type BinanceClient struct {
+	restClient
+	symbols map[string]string
+}
+
+// binanceTicker represents one entry of the /api/v3/ticker/24hr response
+type binanceTicker struct {
+	Symbol             string `json:"symbol"`
+	LastPrice          string `json:"lastPrice"`
+	PriceChangePercent string `json:"priceChangePercent"`
+	QuoteVolume        string `json:"quoteVolume"`
+	CloseTime          int64  `json:"closeTime"`
+}
+
+// BinanceError is a failed Binance request together with the error code and
+// message from the response body, such as -1121 "Invalid symbol."
+type BinanceError struct {
+	Code    int
+	Message string
+	Err     error
+}
+
+func (e *BinanceError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("binance: %v", e.Err)
+	}
+	return fmt.Sprintf("binance: %s (code %d): %v", e.Message, e.Code, e.Err)
+}
+
+func (e *BinanceError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports unknown pairs, which Binance signals with a 400 and code -1121
+func (e *BinanceError) Is(target error) bool {
+	return target == ErrUnknownSymbol && e.Code == binanceInvalidSymbol
+}
+
+// binanceInvalidSymbol is the error code of a request naming an unknown pair
+const binanceInvalidSymbol = -1121
+
+// NewBinanceClient creates a Binance client; symbols maps CoinGecko ids to
+// trading pairs such as "bitcoin" → "BTCUSDT". Coins without a pair are skipped.
+func NewBinanceClient(symbols map[string]string, opts ...Option) *BinanceClient {
+	return &BinanceClient{
+		restClient: newRESTClient(newOptions(binanceBaseURL, opts)),
+		symbols:    symbols,
+	}
+}
+
+// FetchPrices retrieves the 24hr ticker for every mapped coin in one request.
+// Binance rejects the whole request when one pair is unknown; the pairs are then
+// requested one by one and the unknown ones reported in a *domain.PartialError.
+func (c *BinanceClient) FetchPrices(ctx context.Context, coinIDs []string) (map[string]domain.CryptoPrice, error) {
+	if len(coinIDs) == 0 {
+		return nil, fmt.Errorf("no coin IDs provided")
+	}
+
+	bySymbol := make(map[string]string, len(coinIDs))
+	symbols := make([]string, 0, len(coinIDs))
+	for _, id := range coinIDs {
+		symbol, ok := c.symbols[id]
+		if !ok {
+			continue
+		}
+		bySymbol[symbol] = id
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) == 0 {
+		return map[string]domain.CryptoPrice{}, nil
+	}
+
+	tickers, err := c.fetchTickers(ctx, symbols)
+	if errors.Is(err, ErrUnknownSymbol) && len(symbols) > 1 {
+		return c.fetchEach(ctx, symbols, bySymbol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return binancePrices(tickers, bySymbol, c.clock.Now())
+}
+
+// fetchEach requests the ticker of every symbol separately.
+// Coins that fail are reported in a *domain.PartialError unless none could be priced.
+func (c *BinanceClient) fetchEach(ctx context.Context, symbols []string, bySymbol map[string]string) (map[string]domain.CryptoPrice, error) {
+	var tickers []binanceTicker
+	var errs []error
+	var missing []domain.MissingPrice
+
+	for _, symbol := range symbols {
+		t, err := c.fetchTickers(ctx, []string{symbol})
+		if err != nil {
+			if !errors.Is(err, ErrUnknownSymbol) {
+				errs = append(errs, err)
+			}
+			missing = append(missing, domain.MissingPrice{Coin: bySymbol[symbol], Err: err})
+			continue
+		}
+		tickers = append(tickers, t...)
+	}
+
+	if len(tickers) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	result, err := binancePrices(tickers, bySymbol, c.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return result, &domain.PartialError{Missing: missing}
+	}
+	return result, nil
+}
+
+// fetchTickers requests the 24hr tickers of symbols
+func (c *BinanceClient) fetchTickers(ctx context.Context, symbols []string) ([]binanceTicker, error) {
+	param, err := json.Marshal(symbols)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode symbols: %w", err)
+	}
+	endpoint := fmt.Sprintf("%s/api/v3/ticker/24hr?symbols=%s", c.baseURL, url.QueryEscape(string(param)))
+
+	var tickers []binanceTicker
+	if err := c.getJSON(ctx, endpoint, &tickers); err != nil {
+		return nil, binanceError(err)
+	}
+	return tickers, nil
+}
+
+// binancePrices converts tickers fetched at now into prices keyed by the coin id of their symbol
+func binancePrices(tickers []binanceTicker, bySymbol map[string]string, now time.Time) (map[string]domain.CryptoPrice, error) {
+	result := make(map[string]domain.CryptoPrice, len(tickers))
+	now = now.UTC()
+
+	for _, t := range tickers {
+		coinID, ok := bySymbol[t.Symbol]
+		if !ok {
+			continue
+		}
+
+		price, err := strconv.ParseFloat(t.LastPrice, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: invalid lastPrice %q for %s", t.LastPrice, t.Symbol)
+		}
+		change, changeErr := strconv.ParseFloat(t.PriceChangePercent, 64)
+		volume, _ := strconv.ParseFloat(t.QuoteVolume, 64)
+
+		var lastUpdated time.Time
+		if t.CloseTime > 0 {
+			lastUpdated = time.UnixMilli(t.CloseTime).UTC()
+		}
+
+		result[coinID] = domain.CryptoPrice{
+			Coin:         coinID,
+			PriceUSD:     price,
+			Change24h:    change,
+			HasChange24h: changeErr == nil,
+			Volume24h:    volume,
+			FetchedAt:    now,
+			LastUpdated:  lastUpdated,
+			Source:       ProviderBinance,
+			Quotes: map[string]domain.Quote{
+				domain.BaseCurrency: {Price: price, Change24h: change, Volume24h: volume},
+			},
+		}
+	}
+
+	return result, nil
+}
+
+// binanceError attaches the "code" and "msg" fields of a Binance error body
+func binanceError(err error) error {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+	var body struct {
+		Code    int    `json:"code"`
+		Message string `json:"msg"`
+	}
+	if json.Unmarshal([]byte(statusErr.Body), &body) != nil || body.Code == 0 {
+		return err
+	}
+	return &BinanceError{Code: body.Code, Message: body.Message, Err: err}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// binanceServer answers 24hr ticker requests from tickers, keyed by symbol, and
+// rejects a request naming any other symbol as Binance does
+func binanceServer(t *testing.T, tickers map[string]string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var symbols []string
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("symbols")), &symbols); err != nil {
+			t.Errorf("symbols parameter: %v", err)
+		}
+		var results []string
+		for _, symbol := range symbols {
+			body, ok := tickers[symbol]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprint(w, `{"code":-1121,"msg":"Invalid symbol."}`)
+				return
+			}
+			results = append(results, body)
+		}
+		_, _ = fmt.Fprintf(w, "[%s]", strings.Join(results, ","))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func binanceTickerJSON(symbol, price string) string {
+	return fmt.Sprintf(`{"symbol":%q,"lastPrice":%q,"priceChangePercent":"-2.5","quoteVolume":"1500000.5","closeTime":1773057600000}`, symbol, price)
+}
+
+func TestBinanceFetchPrices(t *testing.T) {
+	tickers := map[string]string{
+		"BTCUSDT": binanceTickerJSON("BTCUSDT", "68000.10"),
+		"ETHUSDT": binanceTickerJSON("ETHUSDT", "3500"),
+		"BADUSDT": binanceTickerJSON("BADUSDT", "n/a"),
+	}
+	symbols := map[string]string{
+		"bitcoin":  "BTCUSDT",
+		"ethereum": "ETHUSDT",
+		"nocoin":   "NOCOINUSDT",
+		"badcoin":  "BADUSDT",
+	}
+
+	tests := []struct {
+		name      string
+		coins     []string
+		want      map[string]float64
+		wantCalls int32
+		// missing lists the coins reported in a *domain.PartialError
+		missing []string
+		wantErr bool
+	}{
+		{
+			name:      "one request",
+			coins:     []string{"bitcoin", "ethereum"},
+			want:      map[string]float64{"bitcoin": 68000.10, "ethereum": 3500},
+			wantCalls: 1,
+		},
+		{
+			name:      "coins without a pair are skipped",
+			coins:     []string{"bitcoin", "cardano"},
+			want:      map[string]float64{"bitcoin": 68000.10},
+			wantCalls: 1,
+		},
+		{
+			name:      "unknown symbol retried pair by pair",
+			coins:     []string{"bitcoin", "nocoin", "ethereum"},
+			want:      map[string]float64{"bitcoin": 68000.10, "ethereum": 3500},
+			wantCalls: 4,
+			missing:   []string{"nocoin"},
+		},
+		{
+			name:      "malformed price",
+			coins:     []string{"badcoin"},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := binanceServer(t, tickers)
+			client := NewBinanceClient(symbols, WithBaseURL(srv.URL), WithRetry(RetryPolicy{MaxAttempts: 1}))
+
+			got, err := client.FetchPrices(context.Background(), tt.coins)
+			if n := calls.Load(); n != tt.wantCalls {
+				t.Errorf("sent %d requests, want %d", n, tt.wantCalls)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("FetchPrices = %v, want an error", got)
+				}
+				return
+			}
+
+			var partial *domain.PartialError
+			switch {
+			case len(tt.missing) == 0 && err != nil:
+				t.Fatalf("FetchPrices: %v", err)
+			case len(tt.missing) > 0 && !errors.As(err, &partial):
+				t.Fatalf("FetchPrices error = %v, want a *domain.PartialError", err)
+			}
+			if partial != nil {
+				reasons := partial.Reasons()
+				for _, coin := range tt.missing {
+					if !errors.Is(reasons[coin], ErrUnknownSymbol) {
+						t.Errorf("%s reported with %v, want ErrUnknownSymbol", coin, reasons[coin])
+					}
+				}
+				if len(reasons) != len(tt.missing) {
+					t.Errorf("reported %d missing coins, want %d", len(reasons), len(tt.missing))
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d prices, want %d", len(got), len(tt.want))
+			}
+			for coin, want := range tt.want {
+				p := got[coin]
+				if p.PriceUSD != want || p.Source != ProviderBinance || p.Quotes[domain.BaseCurrency].Price != want {
+					t.Errorf("%s = %v (quote %v) from %q, want %v from binance", coin, p.PriceUSD, p.Quotes[domain.BaseCurrency].Price, p.Source, want)
+				}
+				if p.Change24h != -2.5 || p.Volume24h != 1500000.5 {
+					t.Errorf("%s change %v and volume %v, want -2.5 and 1500000.5", coin, p.Change24h, p.Volume24h)
+				}
+				if want := time.UnixMilli(1773057600000).UTC(); !p.LastUpdated.Equal(want) {
+					t.Errorf("%s last updated %s, want the ticker close time %s", coin, p.LastUpdated, want)
+				}
+			}
+		})
+	}
+}
@@ -2,85 +2,189 @@ package api
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"log"
+	"maps"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/viczuno/go-crypto-bot/internal/domain"
 )
 
 const (
-	baseURL        = "https://api.coingecko.com/api/v3"
-	defaultTimeout = 30 * time.Second
+	baseURL    = "https://api.coingecko.com/api/v3"
+	proBaseURL = "https://pro-api.coingecko.com/api/v3"
 )
 
+// CoinGecko API plans
+const (
+	TierFree = "free"
+	TierDemo = "demo"
+	TierPro  = "pro"
+)
+
+// DefaultRequestsPerMinute returns the published rate limit of a CoinGecko plan
+func DefaultRequestsPerMinute(tier string) int {
+	switch tier {
+	case TierPro:
+		return 500
+	case TierDemo:
+		return 30
+	default:
+		return 10
+	}
+}
+
+// ProviderCoinGecko is the source name recorded for prices from CoinGecko
+const ProviderCoinGecko = "coingecko"
+
 // Ensure CoinGeckoClient implements PriceFetcher
 var _ domain.PriceFetcher = (*CoinGeckoClient)(nil)
 
 // CoinGeckoClient implements domain.PriceFetcher for the CoinGecko API
 type CoinGeckoClient struct {
-	httpClient *http.Client
-	baseURL    string
+	restClient
+	currencies  []string
+	chunkSize   int
+	concurrency int
 }
 
-// coinGeckoResponse represents the API response structure
-type coinGeckoResponse map[string]struct {
-	USD          float64 `json:"usd"`
-	USD24hChange float64 `json:"usd_24h_change"`
-}
+// coinGeckoResponse represents the API response structure, keyed by coin and then
+// by field name such as "usd", "usd_24h_change", "usd_market_cap" or "last_updated_at"
+type coinGeckoResponse map[string]map[string]float64
+
+// NewCoinGeckoClient creates a new CoinGecko API client. A pro key switches
+// to the pro API root unless WithBaseURL overrides it.
+func NewCoinGeckoClient(opts ...Option) *CoinGeckoClient {
+	o := newOptions("", opts)
+	if o.baseURL == "" {
+		o.baseURL = baseURL
+		if o.tier == TierPro {
+			o.baseURL = proBaseURL
+		}
+	}
+
+	rest := newRESTClient(o)
+	switch {
+	case o.apiKey == "":
+	case o.tier == TierPro:
+		rest.header.Set("x-cg-pro-api-key", o.apiKey)
+	case o.tier == TierDemo:
+		rest.header.Set("x-cg-demo-api-key", o.apiKey)
+	}
 
-// NewCoinGeckoClient creates a new CoinGecko API client
-func NewCoinGeckoClient() *CoinGeckoClient {
 	return &CoinGeckoClient{
-		httpClient: &http.Client{
-			Timeout: defaultTimeout,
-		},
-		baseURL: baseURL,
+		restClient:  rest,
+		currencies:  o.currencies,
+		chunkSize:   o.chunkSize,
+		concurrency: o.concurrency,
 	}
 }
 
-// FetchPrices retrieves current prices for the specified coins
+// FetchPrices retrieves current prices for the specified coins. Ids are requested
+// in chunks, a few at a time; when some chunks fail the others are still returned.
 func (c *CoinGeckoClient) FetchPrices(ctx context.Context, coinIDs []string) (map[string]domain.CryptoPrice, error) {
 	if len(coinIDs) == 0 {
 		return nil, fmt.Errorf("no coin IDs provided")
 	}
 
-	ids := strings.Join(coinIDs, ",")
-	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=usd&include_24hr_change=true", c.baseURL, ids)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	chunks := slices.Collect(slices.Chunk(coinIDs, c.chunkSize))
+	results := make([]map[string]domain.CryptoPrice, len(chunks))
+	errs := make([]error, len(chunks))
+	now := c.clock.Now().UTC()
+
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i], errs[i] = c.fetchChunk(ctx, chunk, now)
+		}()
 	}
-
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("network error: %w", err)
+	wg.Wait()
+
+	result := make(map[string]domain.CryptoPrice, len(coinIDs))
+	var failed []error
+	var missing []domain.MissingPrice
+	for i, chunk := range chunks {
+		if errs[i] != nil {
+			log.Printf("CoinGecko chunk %d/%d (%d coins) failed: %v", i+1, len(chunks), len(chunk), errs[i])
+			failed = append(failed, errs[i])
+			for _, id := range chunk {
+				missing = append(missing, domain.MissingPrice{Coin: id, Err: errs[i]})
+			}
+			continue
+		}
+		maps.Copy(result, results[i])
+		// CoinGecko silently leaves out ids it does not know
+		for _, id := range chunk {
+			if _, ok := results[i][id]; !ok {
+				missing = append(missing, domain.MissingPrice{Coin: id, Err: ErrUnknownSymbol})
+			}
+		}
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, resp.Status)
+	if len(failed) == len(chunks) {
+		return nil, errors.Join(failed...)
 	}
+	if len(missing) > 0 {
+		return result, &domain.PartialError{Missing: missing}
+	}
+	return result, nil
+}
+
+// fetchChunk retrieves current prices for up to chunkSize coins in one request
+func (c *CoinGeckoClient) fetchChunk(ctx context.Context, coinIDs []string, now time.Time) (map[string]domain.CryptoPrice, error) {
+	ids := strings.Join(coinIDs, ",")
+	currencies := strings.Join(c.currencies, ",")
+	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=%s&include_24hr_change=true"+
+		"&include_market_cap=true&include_24hr_vol=true&include_last_updated_at=true", c.baseURL, ids, currencies)
 
 	var apiResponse coinGeckoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.getJSON(ctx, url, &apiResponse); err != nil {
+		return nil, err
 	}
 
 	result := make(map[string]domain.CryptoPrice, len(apiResponse))
-	now := time.Now().UTC()
-
 	for coinID, data := range apiResponse {
+		quotes := make(map[string]domain.Quote, len(c.currencies))
+		for _, cur := range c.currencies {
+			price, ok := data[cur]
+			if !ok {
+				continue
+			}
+			quotes[cur] = domain.Quote{
+				Price:     price,
+				Change24h: data[cur+"_24h_change"],
+				MarketCap: data[cur+"_market_cap"],
+				Volume24h: data[cur+"_24h_vol"],
+			}
+		}
+
+		change, hasChange := data["usd_24h_change"]
+
+		var lastUpdated time.Time
+		if ts := data["last_updated_at"]; ts > 0 {
+			lastUpdated = time.Unix(int64(ts), 0).UTC()
+		}
+
 		result[coinID] = domain.CryptoPrice{
-			Coin:      coinID,
-			PriceUSD:  data.USD,
-			Change24h: data.USD24hChange,
-			FetchedAt: now,
+			Coin:         coinID,
+			PriceUSD:     data["usd"],
+			Change24h:    change,
+			HasChange24h: hasChange,
+			MarketCap:    data["usd_market_cap"],
+			Volume24h:    data["usd_24h_vol"],
+			FetchedAt:    now,
+			LastUpdated:  lastUpdated,
+			Source:       ProviderCoinGecko,
+			Quotes:       quotes,
 		}
 	}
 
@@ -95,26 +199,20 @@ type historicalResponse struct {
 // FetchHistoricalPrices retrieves historical prices for a coin
 func (c *CoinGeckoClient) FetchHistoricalPrices(ctx context.Context, coinID string, days int) ([]domain.CryptoPrice, error) {
 	url := fmt.Sprintf("%s/coins/%s/market_chart?vs_currency=usd&days=%d&interval=daily", c.baseURL, coinID, days)
+	return c.fetchMarketChart(ctx, url, coinID)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("network error: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, resp.Status)
-	}
+// FetchHistoricalRange retrieves historical prices for a coin between two instants.
+// CoinGecko picks the granularity: hourly for ranges up to 90 days, daily beyond that.
+func (c *CoinGeckoClient) FetchHistoricalRange(ctx context.Context, coinID string, from, to time.Time) ([]domain.CryptoPrice, error) {
+	url := fmt.Sprintf("%s/coins/%s/market_chart/range?vs_currency=usd&from=%d&to=%d", c.baseURL, coinID, from.Unix(), to.Unix())
+	return c.fetchMarketChart(ctx, url, coinID)
+}
 
+func (c *CoinGeckoClient) fetchMarketChart(ctx context.Context, url, coinID string) ([]domain.CryptoPrice, error) {
 	var data historicalResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.getJSON(ctx, url, &data); err != nil {
+		return nil, err
 	}
 
 	prices := make([]domain.CryptoPrice, 0, len(data.Prices))
@@ -128,10 +226,18 @@ func (c *CoinGeckoClient) FetchHistoricalPrices(ctx context.Context, coinID stri
 		prices = append(prices, domain.CryptoPrice{
 			Coin:      coinID,
 			PriceUSD:  price,
-			Change24h: 0,
 			FetchedAt: timestamp,
+			Source:    ProviderCoinGecko,
 		})
 	}
 
 	return prices, nil
 }
+
+// Ensure CoinGeckoClient implements HistoryFetcher
+var _ domain.HistoryFetcher = (*CoinGeckoClient)(nil)
+
+// Ping checks that the CoinGecko API is reachable
+func (c *CoinGeckoClient) Ping(ctx context.Context) error {
+	return c.getJSON(ctx, c.baseURL+"/ping", nil)
+}
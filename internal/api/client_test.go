@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// coinGeckoServer answers /simple/price for every requested id except "nocoin",
+// which CoinGecko leaves out, and fails any request that includes "broken". It
+// records the id lists requested and the most requests it saw in flight at once.
+type coinGeckoServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	requests    [][]string
+	inFlight    int
+	maxInFlight int
+}
+
+func newCoinGeckoServer(t *testing.T) *coinGeckoServer {
+	t.Helper()
+	s := &coinGeckoServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids := strings.Split(r.URL.Query().Get("ids"), ",")
+		s.mu.Lock()
+		s.requests = append(s.requests, ids)
+		s.inFlight++
+		s.maxInFlight = max(s.maxInFlight, s.inFlight)
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			s.inFlight--
+			s.mu.Unlock()
+		}()
+
+		// Hold the request so concurrent chunks overlap
+		time.Sleep(20 * time.Millisecond)
+		if slices.Contains(ids, "broken") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var entries []string
+		for i, id := range ids {
+			if id != "nocoin" {
+				entries = append(entries, fmt.Sprintf(`%q:{"usd":%d,"usd_24h_change":1.5,"eur":%d,"last_updated_at":1773057600}`, id, 100+i, 90+i))
+			}
+		}
+		_, _ = fmt.Fprintf(w, "{%s}", strings.Join(entries, ","))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestCoinGeckoFetchPricesChunked(t *testing.T) {
+	srv := newCoinGeckoServer(t)
+	client := NewCoinGeckoClient(
+		WithBaseURL(srv.URL),
+		WithCurrencies("eur"),
+		WithChunking(2, 2),
+		WithRetry(RetryPolicy{MaxAttempts: 1}),
+	)
+
+	coins := []string{"bitcoin", "ethereum", "solana", "nocoin", "broken", "cardano", "polkadot"}
+	got, err := client.FetchPrices(context.Background(), coins)
+
+	if len(srv.requests) != 4 {
+		t.Fatalf("sent %d requests, want 4 chunks of up to 2 ids", len(srv.requests))
+	}
+	for _, ids := range srv.requests {
+		if len(ids) > 2 {
+			t.Errorf("requested %v in one chunk, want at most 2 ids", ids)
+		}
+	}
+	if srv.maxInFlight > 2 {
+		t.Errorf("%d requests in flight at once, want at most 2", srv.maxInFlight)
+	}
+
+	var partial *domain.PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("FetchPrices error = %v, want a *domain.PartialError", err)
+	}
+	reasons := partial.Reasons()
+	if !errors.Is(reasons["nocoin"], ErrUnknownSymbol) {
+		t.Errorf("nocoin reported with %v, want ErrUnknownSymbol", reasons["nocoin"])
+	}
+	// The failed chunk takes its other id down with it
+	for _, coin := range []string{"broken", "cardano"} {
+		var statusErr *StatusError
+		if !errors.As(reasons[coin], &statusErr) || statusErr.Code != http.StatusBadRequest {
+			t.Errorf("%s reported with %v, want the chunk's 400", coin, reasons[coin])
+		}
+	}
+	if len(reasons) != 3 {
+		t.Errorf("reported %d missing coins, want 3", len(reasons))
+	}
+
+	want := []string{"bitcoin", "ethereum", "polkadot", "solana"}
+	if keys := slices.Sorted(maps.Keys(got)); !slices.Equal(keys, want) {
+		t.Fatalf("got prices for %v, want %v", keys, want)
+	}
+	btc := got["bitcoin"]
+	if btc.PriceUSD != 100 || btc.Quotes["eur"].Price != 90 || btc.Change24h != 1.5 {
+		t.Errorf("bitcoin = %v USD, %v EUR, change %v, want 100, 90 and 1.5", btc.PriceUSD, btc.Quotes["eur"].Price, btc.Change24h)
+	}
+	if want := time.Unix(1773057600, 0).UTC(); !btc.LastUpdated.Equal(want) {
+		t.Errorf("bitcoin last updated %s, want %s", btc.LastUpdated, want)
+	}
+}
+
+func TestCoinGeckoFetchPricesAllChunksFail(t *testing.T) {
+	srv := newCoinGeckoServer(t)
+	client := NewCoinGeckoClient(WithBaseURL(srv.URL), WithChunking(1, 2), WithRetry(RetryPolicy{MaxAttempts: 1}))
+
+	got, err := client.FetchPrices(context.Background(), []string{"broken"})
+	var partial *domain.PartialError
+	if err == nil || errors.As(err, &partial) || got != nil {
+		t.Errorf("FetchPrices = %v, %v, want no prices and a plain error", got, err)
+	}
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+const coinbaseBaseURL = "https://api.exchange.coinbase.com"
+
+// ProviderCoinbase is the source name recorded for prices from Coinbase
+const ProviderCoinbase = "coinbase"
+
+// Ensure CoinbaseClient implements PriceFetcher
+var _ domain.PriceFetcher = (*CoinbaseClient)(nil)
+
+// CoinbaseClient implements domain.PriceFetcher using the Coinbase Exchange
+// product ticker and 24h stats endpoints
+type CoinbaseClient struct {
+	restClient
+	products map[string]string
+}
+
+// coinbaseTicker represents the /products/{id}/ticker response
+type coinbaseTicker struct {
+	Price string    `json:"price"`
+	Time  time.Time `json:"time"`
+}
+
+// coinbaseStats represents the /products/{id}/stats response covering the last 24 hours
+type coinbaseStats struct {
+	Open   string `json:"open"`
+	Volume string `json:"volume"`
+}
+
+// CoinbaseError is a failed Coinbase request together with the message from the response body
+type CoinbaseError struct {
+	Product string
+	Message string
+	Err     error
+}
+
+func (e *CoinbaseError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("coinbase %s: %v", e.Product, e.Err)
+	}
+	return fmt.Sprintf("coinbase %s: %s: %v", e.Product, e.Message, e.Err)
+}
+
+func (e *CoinbaseError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports unknown products, which Coinbase may also signal with a 400 "NotFound" message
+func (e *CoinbaseError) Is(target error) bool {
+	return target == ErrUnknownSymbol && strings.EqualFold(e.Message, "NotFound")
+}
+
+// NewCoinbaseClient creates a Coinbase client; products maps coin ids to
+// product ids such as "bitcoin" → "BTC-USD"
+func NewCoinbaseClient(products map[string]string, opts ...Option) *CoinbaseClient {
+	return &CoinbaseClient{
+		restClient: newRESTClient(newOptions(coinbaseBaseURL, opts)),
+		products:   products,
+	}
+}
+
+// FetchPrices retrieves the ticker and 24h stats for each mapped coin.
+// Coins that fail are reported in a *domain.PartialError unless none could be priced.
+func (c *CoinbaseClient) FetchPrices(ctx context.Context, coinIDs []string) (map[string]domain.CryptoPrice, error) {
+	if len(coinIDs) == 0 {
+		return nil, fmt.Errorf("no coin IDs provided")
+	}
+
+	result := make(map[string]domain.CryptoPrice, len(coinIDs))
+	var errs []error
+	var missing []domain.MissingPrice
+
+	for _, id := range coinIDs {
+		product, ok := c.products[id]
+		if !ok {
+			continue
+		}
+
+		price, err := c.fetchProduct(ctx, id, product)
+		if err != nil {
+			if !errors.Is(err, ErrUnknownSymbol) {
+				errs = append(errs, err)
+			}
+			missing = append(missing, domain.MissingPrice{Coin: id, Err: err})
+			continue
+		}
+		result[id] = price
+	}
+
+	if len(result) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if len(missing) > 0 {
+		return result, &domain.PartialError{Missing: missing}
+	}
+	return result, nil
+}
+
+func (c *CoinbaseClient) fetchProduct(ctx context.Context, coinID, product string) (domain.CryptoPrice, error) {
+	base := fmt.Sprintf("%s/products/%s", c.baseURL, url.PathEscape(product))
+
+	var ticker coinbaseTicker
+	if err := c.getJSON(ctx, base+"/ticker", &ticker); err != nil {
+		return domain.CryptoPrice{}, coinbaseError(product, err)
+	}
+	var stats coinbaseStats
+	if err := c.getJSON(ctx, base+"/stats", &stats); err != nil {
+		return domain.CryptoPrice{}, coinbaseError(product, err)
+	}
+
+	price, err := strconv.ParseFloat(ticker.Price, 64)
+	if err != nil {
+		return domain.CryptoPrice{}, fmt.Errorf("failed to decode response: invalid price %q for %s", ticker.Price, product)
+	}
+
+	var change float64
+	open, err := strconv.ParseFloat(stats.Open, 64)
+	hasChange := err == nil && open != 0
+	if hasChange {
+		change = (price - open) / open * 100
+	}
+
+	// Coinbase reports volume in the base asset; convert at the current price
+	var volume float64
+	if base, err := strconv.ParseFloat(stats.Volume, 64); err == nil {
+		volume = base * price
+	}
+
+	return domain.CryptoPrice{
+		Coin:         coinID,
+		PriceUSD:     price,
+		Change24h:    change,
+		HasChange24h: hasChange,
+		Volume24h:    volume,
+		FetchedAt:    c.clock.Now().UTC(),
+		LastUpdated:  ticker.Time.UTC(),
+		Source:       ProviderCoinbase,
+		Quotes: map[string]domain.Quote{
+			domain.BaseCurrency: {Price: price, Change24h: change, Volume24h: volume},
+		},
+	}, nil
+}
+
+// coinbaseError attaches the "message" field of a Coinbase error body
+func coinbaseError(product string, err error) error {
+	cbErr := &CoinbaseError{Product: product, Err: err}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		var body struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal([]byte(statusErr.Body), &body) == nil {
+			cbErr.Message = body.Message
+		}
+	}
+	return cbErr
+}
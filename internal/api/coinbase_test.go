@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+func TestCoinbaseFetchPrices(t *testing.T) {
+	at := time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/products/BTC-USD/ticker", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"price":"68000","time":%q}`, at.Format(time.RFC3339Nano))
+	})
+	mux.HandleFunc("/products/BTC-USD/stats", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"open":"64000","volume":"2"}`)
+	})
+	mux.HandleFunc("/products/NOCOIN-USD/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, `{"message":"NotFound"}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewCoinbaseClient(map[string]string{
+		"bitcoin": "BTC-USD",
+		"nocoin":  "NOCOIN-USD",
+	}, WithBaseURL(srv.URL), WithClock(domain.FixedClock(at.Add(time.Second))))
+
+	got, err := client.FetchPrices(context.Background(), []string{"bitcoin", "nocoin"})
+	var partial *domain.PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("FetchPrices error = %v, want a *domain.PartialError", err)
+	}
+	if reason := partial.Reasons()["nocoin"]; !errors.Is(reason, ErrUnknownSymbol) {
+		t.Errorf("nocoin reported with %v, want ErrUnknownSymbol", reason)
+	}
+
+	btc, ok := got["bitcoin"]
+	if !ok {
+		t.Fatal("no price for bitcoin")
+	}
+	if btc.PriceUSD != 68000 || btc.Change24h != 6.25 || !btc.HasChange24h {
+		t.Errorf("bitcoin = %v with change %v (known %v), want 68000 with 6.25", btc.PriceUSD, btc.Change24h, btc.HasChange24h)
+	}
+	if btc.Volume24h != 136000 || !btc.LastUpdated.Equal(at) || btc.Source != ProviderCoinbase {
+		t.Errorf("bitcoin volume %v at %s from %q, want 136000 at %s from coinbase", btc.Volume24h, btc.LastUpdated, btc.Source, at)
+	}
+	if want := at.Add(time.Second); !btc.FetchedAt.Equal(want) {
+		t.Errorf("bitcoin fetched at %s, want the client clock's %s", btc.FetchedAt, want)
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// maxMarketsPage is the largest page size /coins/markets accepts
+const maxMarketsPage = 250
+
+// CoinCandidate is a coin returned by the CoinGecko discovery endpoints
+type CoinCandidate struct {
+	ID     string `json:"id"`
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+	// MarketCapRank is zero when CoinGecko does not rank the coin
+	MarketCapRank int `json:"market_cap_rank"`
+	// Exact is set when the symbol or name equals the query, ignoring case
+	Exact bool `json:"-"`
+}
+
+// Metadata converts the candidate into the coin configuration format
+func (c CoinCandidate) Metadata() domain.CoinMetadata {
+	return domain.CoinMetadata{ID: c.ID, Name: c.Name, Symbol: strings.ToUpper(c.Symbol)}
+}
+
+// ListCoins retrieves every coin CoinGecko knows (/coins/list)
+func (c *CoinGeckoClient) ListCoins(ctx context.Context) ([]CoinCandidate, error) {
+	var coins []CoinCandidate
+	if err := c.getJSON(ctx, c.baseURL+"/coins/list", &coins); err != nil {
+		return nil, err
+	}
+	return coins, nil
+}
+
+// SearchCoins runs a fuzzy search over coin names and symbols (/search), best matches first
+func (c *CoinGeckoClient) SearchCoins(ctx context.Context, query string) ([]CoinCandidate, error) {
+	var resp struct {
+		Coins []CoinCandidate `json:"coins"`
+	}
+	endpoint := fmt.Sprintf("%s/search?query=%s", c.baseURL, url.QueryEscape(query))
+	if err := c.getJSON(ctx, endpoint, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Coins, nil
+}
+
+// ResolveCoin returns the ids a symbol or name may refer to. Coins whose symbol
+// or name matches exactly come first, ordered by market cap rank, followed by the
+// remaining fuzzy search results.
+func (c *CoinGeckoClient) ResolveCoin(ctx context.Context, query string) ([]CoinCandidate, error) {
+	found, err := c.SearchCoins(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search coins: %w", err)
+	}
+	all, err := c.ListCoins(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coins: %w", err)
+	}
+
+	ranks := make(map[string]int, len(found))
+	for _, coin := range found {
+		ranks[coin.ID] = coin.MarketCapRank
+	}
+
+	var exact []CoinCandidate
+	seen := make(map[string]bool)
+	for _, coin := range all {
+		if !strings.EqualFold(coin.Symbol, query) && !strings.EqualFold(coin.Name, query) {
+			continue
+		}
+		coin.MarketCapRank = ranks[coin.ID]
+		coin.Exact = true
+		exact = append(exact, coin)
+		seen[coin.ID] = true
+	}
+	slices.SortStableFunc(exact, func(a, b CoinCandidate) int {
+		return cmp.Compare(rankOrder(a.MarketCapRank), rankOrder(b.MarketCapRank))
+	})
+
+	for _, coin := range found {
+		if !seen[coin.ID] {
+			exact = append(exact, coin)
+		}
+	}
+	return exact, nil
+}
+
+// rankOrder sorts unranked coins after ranked ones
+func rankOrder(rank int) int {
+	if rank <= 0 {
+		return math.MaxInt
+	}
+	return rank
+}
+
+// TopCoins retrieves the n largest coins by market cap (/coins/markets)
+func (c *CoinGeckoClient) TopCoins(ctx context.Context, n int) ([]CoinCandidate, error) {
+	coins := make([]CoinCandidate, 0, n)
+	perPage := min(n, maxMarketsPage)
+	for page := 1; len(coins) < n; page++ {
+		endpoint := fmt.Sprintf("%s/coins/markets?vs_currency=usd&order=market_cap_desc&per_page=%d&page=%d",
+			c.baseURL, perPage, page)
+
+		var batch []CoinCandidate
+		if err := c.getJSON(ctx, endpoint, &batch); err != nil {
+			return nil, err
+		}
+		coins = append(coins, batch...)
+		if len(batch) < perPage {
+			break
+		}
+	}
+	if len(coins) > n {
+		coins = coins[:n]
+	}
+	return coins, nil
+}
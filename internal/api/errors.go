@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Sentinel errors shared by all providers; match them with errors.Is
+var (
+	// ErrRateLimited means the provider rejected the request because of rate limits
+	ErrRateLimited = errors.New("rate limited")
+	// ErrBudgetExhausted means the local rate limiter refused a request that could not
+	// be sent before the deadline; unlike ErrRateLimited it is not retried
+	ErrBudgetExhausted = errors.New("request budget exhausted")
+	// ErrUnavailable means the provider failed on its side or is temporarily down
+	ErrUnavailable = errors.New("provider unavailable")
+	// ErrUnknownSymbol means the provider does not know the requested coin, pair or product
+	ErrUnknownSymbol = errors.New("unknown symbol")
+	// ErrNoQuorum means too few providers agreed on a coin's price
+	ErrNoQuorum = errors.New("not enough agreeing sources")
+	// ErrDecode means the provider answered with a body that could not be decoded
+	ErrDecode = errors.New("malformed response")
+)
+
+// StatusError is returned for non-200 HTTP responses
+type StatusError struct {
+	Code   int
+	Status string
+	// Body holds the start of the response body, which often explains the failure
+	Body string
+	// RetryAfter is the delay requested by the server's Retry-After header, if any
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.Code, e.Status)
+}
+
+// Is maps HTTP status classes onto the sentinel errors
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.Code == http.StatusTooManyRequests
+	case ErrUnavailable:
+		return e.Code >= 500
+	case ErrUnknownSymbol:
+		return e.Code == http.StatusNotFound
+	}
+	return false
+}
+
+// NetworkError is returned when no HTTP response was received
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("network error: %v", e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned when a response body is not the expected JSON
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode response: %v", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrDecode
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// Ensure FallbackFetcher implements PriceFetcher
+var _ domain.PriceFetcher = (*FallbackFetcher)(nil)
+
+// Provider pairs a price source with the name recorded as the provenance of its prices
+type Provider struct {
+	Name    string
+	Fetcher domain.PriceFetcher
+}
+
+// FallbackFetcher implements domain.PriceFetcher by trying providers in order.
+// Coins a provider fails on or omits are requested from the next provider.
+type FallbackFetcher struct {
+	providers []Provider
+}
+
+// NewFallbackFetcher creates a fetcher that tries providers in the given order
+func NewFallbackFetcher(providers ...Provider) *FallbackFetcher {
+	return &FallbackFetcher{providers: providers}
+}
+
+// FetchPrices retrieves prices from the first provider that has them, per coin.
+// Coins no provider had are reported in a *domain.PartialError with the last
+// provider's reason; it fails only if no provider returned any price.
+func (f *FallbackFetcher) FetchPrices(ctx context.Context, coinIDs []string) (map[string]domain.CryptoPrice, error) {
+	if len(f.providers) == 0 {
+		return nil, errors.New("no price providers configured")
+	}
+
+	result := make(map[string]domain.CryptoPrice, len(coinIDs))
+	remaining := coinIDs
+	reasons := make(map[string]error, len(coinIDs))
+	var errs []error
+
+	for _, p := range f.providers {
+		if len(remaining) == 0 || ctx.Err() != nil {
+			break
+		}
+
+		prices, err := p.Fetcher.FetchPrices(ctx, remaining)
+		var perCoin map[string]error
+		var partial *domain.PartialError
+		if errors.As(err, &partial) {
+			perCoin = partial.Reasons()
+		} else if err != nil {
+			log.Printf("Provider %s failed: %v", p.Name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+		}
+
+		var missing []string
+		for _, id := range remaining {
+			price, ok := prices[id]
+			if !ok {
+				reason := perCoin[id]
+				switch {
+				case reason != nil:
+				case err != nil:
+					reason = err
+				default:
+					reason = domain.ErrNoData
+				}
+				reasons[id] = fmt.Errorf("%s: %w", p.Name, reason)
+				missing = append(missing, id)
+				continue
+			}
+			if price.Source == "" {
+				price.Source = p.Name
+			}
+			result[id] = price
+		}
+
+		if len(missing) > 0 && len(missing) < len(remaining) {
+			log.Printf("Provider %s had no price for %d coins", p.Name, len(missing))
+		}
+		remaining = missing
+	}
+
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(result) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+	}
+	if len(remaining) > 0 {
+		missing := make([]domain.MissingPrice, len(remaining))
+		for i, id := range remaining {
+			missing[i] = domain.MissingPrice{Coin: id, Err: reasons[id]}
+		}
+		return result, &domain.PartialError{Missing: missing}
+	}
+
+	return result, nil
+}
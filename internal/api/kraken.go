@@ -0,0 +1,248 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+const krakenBaseURL = "https://api.kraken.com"
+
+// ProviderKraken is the source name recorded for prices from Kraken
+const ProviderKraken = "kraken"
+
+// Ensure KrakenClient implements PriceFetcher
+var _ domain.PriceFetcher = (*KrakenClient)(nil)
+
+// KrakenClient implements domain.PriceFetcher using the Kraken public Ticker endpoint
+type KrakenClient struct {
+	restClient
+	pairs map[string]string
+}
+
+// krakenResponse is the envelope of every Kraken public API response
+type krakenResponse struct {
+	Error  []string                `json:"error"`
+	Result map[string]krakenTicker `json:"result"`
+}
+
+// krakenTicker holds the Ticker fields used here; arrays are [today, last 24 hours]
+type krakenTicker struct {
+	Close  []string `json:"c"` // [price, lot volume]
+	Volume []string `json:"v"`
+	VWAP   []string `json:"p"`
+}
+
+// KrakenError carries the error strings Kraken returns alongside HTTP 200
+type KrakenError struct {
+	Messages []string
+}
+
+func (e *KrakenError) Error() string {
+	return "kraken: " + strings.Join(e.Messages, "; ")
+}
+
+// Is classifies Kraken error codes such as "EAPI:Rate limit exceeded"
+func (e *KrakenError) Is(target error) bool {
+	for _, msg := range e.Messages {
+		switch {
+		case target == ErrRateLimited && strings.Contains(msg, "Rate limit"):
+			return true
+		case target == ErrUnavailable && strings.HasPrefix(msg, "EService:"):
+			return true
+		case target == ErrUnknownSymbol && strings.HasPrefix(msg, "EQuery:Unknown asset pair"):
+			return true
+		}
+	}
+	return false
+}
+
+// NewKrakenClient creates a Kraken client; pairs maps coin ids to Kraken pair
+// names as they appear in Ticker results, such as "bitcoin" → "XXBTZUSD"
+func NewKrakenClient(pairs map[string]string, opts ...Option) *KrakenClient {
+	return &KrakenClient{
+		restClient: newRESTClient(newOptions(krakenBaseURL, opts)),
+		pairs:      pairs,
+	}
+}
+
+// FetchPrices retrieves tickers for every mapped coin in one request.
+// Kraken rejects the whole request when one pair is unknown, and may answer under
+// another name for a pair, such as "XXBTZUSD" for "XBTUSD"; those pairs are then
+// requested one by one and the ones still without a ticker reported in a *domain.PartialError.
+// Kraken has no rolling 24h change, so HasChange24h is false and the change is
+// derived from stored history instead. Tickers carry no time either, so LastUpdated is left zero.
+func (c *KrakenClient) FetchPrices(ctx context.Context, coinIDs []string) (map[string]domain.CryptoPrice, error) {
+	if len(coinIDs) == 0 {
+		return nil, fmt.Errorf("no coin IDs provided")
+	}
+
+	byPair := make(map[string]string, len(coinIDs))
+	pairs := make([]string, 0, len(coinIDs))
+	for _, id := range coinIDs {
+		pair, ok := c.pairs[id]
+		if !ok {
+			continue
+		}
+		byPair[pair] = id
+		pairs = append(pairs, pair)
+	}
+	if len(pairs) == 0 {
+		return map[string]domain.CryptoPrice{}, nil
+	}
+
+	tickers, err := c.fetchTickers(ctx, pairs)
+	if errors.Is(err, ErrUnknownSymbol) && len(pairs) > 1 {
+		return c.fetchEach(ctx, pairs, byPair)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs) == 1 {
+		if tickers, err = onlyTicker(pairs[0], tickers); err != nil {
+			return nil, err
+		}
+	}
+
+	var unmatched []string
+	for _, pair := range pairs {
+		if _, ok := tickers[pair]; !ok {
+			unmatched = append(unmatched, pair)
+		}
+	}
+	if len(unmatched) == 0 {
+		return krakenPrices(tickers, byPair, c.clock.Now())
+	}
+
+	result, err := krakenPrices(tickers, byPair, c.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	rest, err := c.fetchEach(ctx, unmatched, byPair)
+	maps.Copy(result, rest)
+	if err == nil {
+		return result, nil
+	}
+	// The matched pairs are priced whatever happened to the others
+	var partial *domain.PartialError
+	if !errors.As(err, &partial) {
+		partial = &domain.PartialError{}
+		for _, pair := range unmatched {
+			partial.Missing = append(partial.Missing, domain.MissingPrice{Coin: byPair[pair], Err: err})
+		}
+	}
+	return result, partial
+}
+
+// fetchEach requests the ticker of every pair separately, so that each answer
+// belongs to its pair whatever name Kraken gives it.
+// Coins that fail are reported in a *domain.PartialError unless none could be priced.
+func (c *KrakenClient) fetchEach(ctx context.Context, pairs []string, byPair map[string]string) (map[string]domain.CryptoPrice, error) {
+	tickers := make(map[string]krakenTicker, len(pairs))
+	var errs []error
+	var missing []domain.MissingPrice
+
+	for _, pair := range pairs {
+		t, err := c.fetchTickers(ctx, []string{pair})
+		if err == nil {
+			t, err = onlyTicker(pair, t)
+		}
+		if err != nil {
+			if !errors.Is(err, ErrUnknownSymbol) {
+				errs = append(errs, err)
+			}
+			missing = append(missing, domain.MissingPrice{Coin: byPair[pair], Err: err})
+			continue
+		}
+		tickers[pair] = t[pair]
+	}
+
+	if len(tickers) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	result, err := krakenPrices(tickers, byPair, c.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return result, &domain.PartialError{Missing: missing}
+	}
+	return result, nil
+}
+
+// fetchTickers requests the tickers of pairs, keyed by the names Kraken returns them under
+func (c *KrakenClient) fetchTickers(ctx context.Context, pairs []string) (map[string]krakenTicker, error) {
+	endpoint := fmt.Sprintf("%s/0/public/Ticker?pair=%s", c.baseURL, url.QueryEscape(strings.Join(pairs, ",")))
+
+	var resp krakenResponse
+	check := func() error {
+		if len(resp.Error) == 0 {
+			return nil
+		}
+		err := &KrakenError{Messages: resp.Error}
+		resp = krakenResponse{}
+		return err
+	}
+	if err := c.getCheckedJSON(ctx, endpoint, &resp, check); err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+// onlyTicker keys the single ticker of a one-pair request by the requested pair,
+// whatever name Kraken answered under
+func onlyTicker(pair string, tickers map[string]krakenTicker) (map[string]krakenTicker, error) {
+	if len(tickers) != 1 {
+		return nil, fmt.Errorf("kraken: no ticker for %s: %w", pair, ErrUnknownSymbol)
+	}
+	for _, t := range tickers {
+		return map[string]krakenTicker{pair: t}, nil
+	}
+	return nil, nil
+}
+
+// krakenPrices converts tickers fetched at now into prices keyed by the coin id of their pair
+func krakenPrices(tickers map[string]krakenTicker, byPair map[string]string, now time.Time) (map[string]domain.CryptoPrice, error) {
+	result := make(map[string]domain.CryptoPrice, len(tickers))
+	now = now.UTC()
+
+	for pair, t := range tickers {
+		coinID, ok := byPair[pair]
+		if !ok || len(t.Close) == 0 {
+			continue
+		}
+
+		price, err := strconv.ParseFloat(t.Close[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: invalid close price %q for %s", t.Close[0], pair)
+		}
+
+		// Kraken reports 24h volume in the base asset; convert with the 24h VWAP
+		var volume float64
+		if len(t.Volume) > 1 && len(t.VWAP) > 1 {
+			base, _ := strconv.ParseFloat(t.Volume[1], 64)
+			vwap, _ := strconv.ParseFloat(t.VWAP[1], 64)
+			volume = base * vwap
+		}
+
+		result[coinID] = domain.CryptoPrice{
+			Coin:      coinID,
+			PriceUSD:  price,
+			Volume24h: volume,
+			FetchedAt: now,
+			Source:    ProviderKraken,
+			Quotes: map[string]domain.Quote{
+				domain.BaseCurrency: {Price: price, Volume24h: volume},
+			},
+		}
+	}
+
+	return result, nil
+}
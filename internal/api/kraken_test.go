@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// krakenServer answers Ticker requests from tickers, keyed by the name each pair
+// is answered under; aliases maps requested pair names to those keys
+func krakenServer(t *testing.T, tickers map[string]string, aliases map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var results []string
+		for _, pair := range strings.Split(r.URL.Query().Get("pair"), ",") {
+			key := pair
+			if alias, ok := aliases[pair]; ok {
+				key = alias
+			}
+			body, ok := tickers[key]
+			if !ok {
+				_, _ = fmt.Fprint(w, `{"error":["EQuery:Unknown asset pair"]}`)
+				return
+			}
+			results = append(results, fmt.Sprintf("%q:%s", key, body))
+		}
+		_, _ = fmt.Fprintf(w, `{"error":[],"result":{%s}}`, strings.Join(results, ","))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func krakenTickerJSON(price string) string {
+	return fmt.Sprintf(`{"c":[%q,"0.1"],"v":["10","100"],"p":["1","%s"],"o":"1"}`, price, price)
+}
+
+func TestKrakenFetchPrices(t *testing.T) {
+	tickers := map[string]string{
+		"XXBTZUSD": krakenTickerJSON("68000.5"),
+		"XETHZUSD": krakenTickerJSON("3500"),
+	}
+	pairs := map[string]string{
+		"bitcoin":  "XBTUSD",
+		"ethereum": "XETHZUSD",
+		"nocoin":   "NOCOINUSD",
+	}
+
+	tests := []struct {
+		name    string
+		coins   []string
+		aliases map[string]string
+		want    map[string]float64
+		// missing lists the coins reported in a *domain.PartialError
+		missing []string
+	}{
+		{
+			name:  "one request",
+			coins: []string{"ethereum"},
+			want:  map[string]float64{"ethereum": 3500},
+		},
+		{
+			name:    "lone pair answered under another name",
+			coins:   []string{"bitcoin"},
+			aliases: map[string]string{"XBTUSD": "XXBTZUSD"},
+			want:    map[string]float64{"bitcoin": 68000.5},
+		},
+		{
+			name:    "renamed pair requested again on its own",
+			coins:   []string{"bitcoin", "ethereum"},
+			aliases: map[string]string{"XBTUSD": "XXBTZUSD"},
+			want:    map[string]float64{"bitcoin": 68000.5, "ethereum": 3500},
+		},
+		{
+			name:    "unknown pair",
+			coins:   []string{"bitcoin", "ethereum", "nocoin"},
+			aliases: map[string]string{"XBTUSD": "XXBTZUSD"},
+			want:    map[string]float64{"bitcoin": 68000.5, "ethereum": 3500},
+			missing: []string{"nocoin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := krakenServer(t, tickers, tt.aliases)
+			client := NewKrakenClient(pairs, WithBaseURL(srv.URL))
+
+			got, err := client.FetchPrices(context.Background(), tt.coins)
+			var partial *domain.PartialError
+			switch {
+			case len(tt.missing) == 0 && err != nil:
+				t.Fatalf("FetchPrices: %v", err)
+			case len(tt.missing) > 0 && !errors.As(err, &partial):
+				t.Fatalf("FetchPrices error = %v, want a *domain.PartialError", err)
+			}
+			if partial != nil {
+				reasons := partial.Reasons()
+				for _, coin := range tt.missing {
+					if !errors.Is(reasons[coin], ErrUnknownSymbol) {
+						t.Errorf("%s reported with %v, want ErrUnknownSymbol", coin, reasons[coin])
+					}
+				}
+				if len(reasons) != len(tt.missing) {
+					t.Errorf("reported %d missing coins, want %d", len(reasons), len(tt.missing))
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d prices, want %d", len(got), len(tt.want))
+			}
+			for coin, want := range tt.want {
+				p := got[coin]
+				if p.PriceUSD != want || p.Source != ProviderKraken {
+					t.Errorf("%s = %v from %q, want %v from kraken", coin, p.PriceUSD, p.Source, want)
+				}
+				// The day's open is not a 24h change
+				if p.HasChange24h {
+					t.Errorf("%s has a 24h change of %v, want none", coin, p.Change24h)
+				}
+				if wantVolume := 100 * want; p.Volume24h != wantVolume {
+					t.Errorf("%s volume = %v, want %v", coin, p.Volume24h, wantVolume)
+				}
+			}
+		})
+	}
+}
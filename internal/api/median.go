@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// SourceMedian is the source recorded for prices aggregated by MedianFetcher
+const SourceMedian = "median"
+
+// Ensure MedianFetcher implements PriceFetcher
+var _ domain.PriceFetcher = (*MedianFetcher)(nil)
+
+// MedianFetcher implements domain.PriceFetcher by querying all providers
+// concurrently and taking the median price per coin. Quotes further than
+// tolerancePct from the median are discarded, and a coin is only priced when
+// at least quorum providers agree.
+type MedianFetcher struct {
+	providers    []Provider
+	tolerancePct float64
+	quorum       int
+}
+
+// NewMedianFetcher creates a fetcher that aggregates the given providers
+func NewMedianFetcher(tolerancePct float64, quorum int, providers ...Provider) *MedianFetcher {
+	return &MedianFetcher{
+		providers:    providers,
+		tolerancePct: tolerancePct,
+		quorum:       max(quorum, 1),
+	}
+}
+
+// providerResult is the outcome of one provider's fetch
+type providerResult struct {
+	prices map[string]domain.CryptoPrice
+	err    error
+}
+
+// FetchPrices retrieves prices from every provider and aggregates them per coin.
+// Coins that miss quorum are reported in a *domain.PartialError; it fails only
+// if no coin reached quorum.
+func (f *MedianFetcher) FetchPrices(ctx context.Context, coinIDs []string) (map[string]domain.CryptoPrice, error) {
+	if len(f.providers) == 0 {
+		return nil, errors.New("no price providers configured")
+	}
+
+	results := make([]providerResult, len(f.providers))
+	var wg sync.WaitGroup
+	for i, p := range f.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			prices, err := p.Fetcher.FetchPrices(ctx, coinIDs)
+			results[i] = providerResult{prices: prices, err: err}
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for i, r := range results {
+		if r.err != nil {
+			log.Printf("Provider %s failed: %v", f.providers[i].Name, r.err)
+			errs = append(errs, fmt.Errorf("%s: %w", f.providers[i].Name, r.err))
+		}
+	}
+
+	result := make(map[string]domain.CryptoPrice, len(coinIDs))
+	var missing []domain.MissingPrice
+	for _, id := range coinIDs {
+		var quotes []domain.CryptoPrice
+		for i, r := range results {
+			price, ok := r.prices[id]
+			if !ok || price.PriceUSD <= 0 {
+				continue
+			}
+			price.Source = f.providers[i].Name
+			quotes = append(quotes, price)
+		}
+
+		price, err := f.aggregate(id, quotes)
+		if err != nil {
+			log.Printf("Skipping %s: %v", id, err)
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			missing = append(missing, domain.MissingPrice{Coin: id, Err: err})
+			continue
+		}
+		result[id] = price
+	}
+
+	if len(result) == 0 {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+		}
+		return nil, fmt.Errorf("no coin reached quorum: %w", errors.Join(errs...))
+	}
+	if len(missing) > 0 {
+		return result, &domain.PartialError{Missing: missing}
+	}
+
+	return result, nil
+}
+
+// aggregate combines the quotes for one coin, given in provider order
+func (f *MedianFetcher) aggregate(coinID string, quotes []domain.CryptoPrice) (domain.CryptoPrice, error) {
+	if len(quotes) < f.quorum {
+		return domain.CryptoPrice{}, fmt.Errorf("%w: %d of %d required", ErrNoQuorum, len(quotes), f.quorum)
+	}
+
+	all := make([]float64, len(quotes))
+	for i, q := range quotes {
+		all[i] = q.PriceUSD
+	}
+	mid := median(all)
+
+	var accepted []domain.CryptoPrice
+	for _, q := range quotes {
+		if deviation := math.Abs(q.PriceUSD-mid) / mid * 100; deviation > f.tolerancePct {
+			log.Printf("Rejecting %s price %.6g from %s: %.2f%% from median %.6g", coinID, q.PriceUSD, q.Source, deviation, mid)
+			continue
+		}
+		accepted = append(accepted, q)
+	}
+	if len(accepted) < f.quorum {
+		return domain.CryptoPrice{}, fmt.Errorf("%w: %d of %d required within %.2f%% of the median", ErrNoQuorum, len(accepted), f.quorum, f.tolerancePct)
+	}
+
+	prices := make([]float64, len(accepted))
+	changes := make([]float64, 0, len(accepted))
+	sources := make([]string, len(accepted))
+	for i, q := range accepted {
+		prices[i] = q.PriceUSD
+		if q.HasChange24h {
+			changes = append(changes, q.Change24h)
+		}
+		sources[i] = q.Source
+	}
+
+	// Start from the first accepted provider's data, which keeps its market cap
+	// and other currencies, and replace the USD figures with the aggregate.
+	// Its other currencies are scaled to the median, keeping that provider's exchange rates.
+	price := accepted[0]
+	price.PriceUSD = median(prices)
+	price.Change24h = 0
+	price.HasChange24h = len(changes) > 0
+	if price.HasChange24h {
+		price.Change24h = median(changes)
+	}
+	price.Source = SourceMedian
+	price.Sources = sources
+	// Spread is measured between the accepted quotes; rejected outliers are logged above
+	price.Spread = (slices.Max(prices) - slices.Min(prices)) / price.PriceUSD * 100
+	for _, q := range accepted {
+		price.LastUpdated = later(price.LastUpdated, q.LastUpdated)
+		if price.MarketCap == 0 {
+			price.MarketCap = q.MarketCap
+		}
+	}
+
+	scale := price.PriceUSD / accepted[0].PriceUSD
+	price.Quotes = maps.Clone(price.Quotes)
+	if price.Quotes == nil {
+		price.Quotes = make(map[string]domain.Quote, 1)
+	}
+	for currency, q := range price.Quotes {
+		q.Price *= scale
+		price.Quotes[currency] = q
+	}
+	base := price.Quotes[domain.BaseCurrency]
+	base.Price = price.PriceUSD
+	base.Change24h = price.Change24h
+	base.MarketCap = price.MarketCap
+	price.Quotes[domain.BaseCurrency] = base
+
+	return price, nil
+}
+
+// median returns the middle value, or the mean of the two middle values
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// later returns the more recent of two timestamps
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+	"github.com/viczuno/go-crypto-bot/internal/fake"
+)
+
+func TestMedianFetchPrices(t *testing.T) {
+	quote := func(usd, eur float64) map[string]domain.CryptoPrice {
+		return map[string]domain.CryptoPrice{"bitcoin": {
+			Coin:     "bitcoin",
+			PriceUSD: usd,
+			Quotes: map[string]domain.Quote{
+				"usd": {Price: usd},
+				"eur": {Price: eur},
+			},
+		}}
+	}
+	fetcher := NewMedianFetcher(1, 2,
+		Provider{Name: "coingecko", Fetcher: &fake.Fetcher{Prices: quote(101, 90.9)}},
+		Provider{Name: "binance", Fetcher: &fake.Fetcher{Prices: quote(100, 0)}},
+		Provider{Name: "kraken", Fetcher: &fake.Fetcher{Prices: quote(99.5, 0)}},
+		Provider{Name: "outlier", Fetcher: &fake.Fetcher{Prices: quote(150, 0)}},
+	)
+
+	got, err := fetcher.FetchPrices(context.Background(), []string{"bitcoin", "ethereum"})
+	var partial *domain.PartialError
+	if !errors.As(err, &partial) || !errors.Is(partial.Reasons()["ethereum"], ErrNoQuorum) {
+		t.Fatalf("FetchPrices error = %v, want ethereum reported without quorum", err)
+	}
+
+	btc := got["bitcoin"]
+	if btc.PriceUSD != 100 || btc.Source != SourceMedian || len(btc.Sources) != 3 {
+		t.Errorf("bitcoin = %v from %v, want the median 100 of the three agreeing providers", btc.PriceUSD, btc.Sources)
+	}
+	// The rejected outlier does not widen the spread
+	if want := 1.5; math.Abs(btc.Spread-want) > 1e-9 {
+		t.Errorf("spread = %v, want %v", btc.Spread, want)
+	}
+	// EUR comes from the first provider, scaled to the median
+	if usd, eur := btc.Quotes["usd"].Price, btc.Quotes["eur"].Price; usd != 100 || math.Abs(eur-90) > 1e-9 {
+		t.Errorf("quotes = %v USD and %v EUR, want 100 and 90", usd, eur)
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// OHLCDays lists the ranges /coins/{id}/ohlc accepts without a paid plan
+var OHLCDays = []int{1, 7, 14, 30, 90, 180, 365}
+
+// ohlcInterval returns the candle width CoinGecko uses for a range of days:
+// 30 minutes up to 2 days, 4 hours up to 30 days and 4 days beyond
+func ohlcInterval(days int) (string, time.Duration) {
+	switch {
+	case days <= 2:
+		return "30m", 30 * time.Minute
+	case days <= 30:
+		return "4h", 4 * time.Hour
+	default:
+		return "4d", 96 * time.Hour
+	}
+}
+
+// FetchOHLC retrieves the USD candles of a coin over the last days days (/coins/{id}/ohlc).
+// CoinGecko stamps each candle with its close time; OpenTime is derived from the interval.
+func (c *CoinGeckoClient) FetchOHLC(ctx context.Context, coinID string, days int) ([]domain.Candle, error) {
+	if !slices.Contains(OHLCDays, days) {
+		return nil, fmt.Errorf("unsupported OHLC range of %d days, want one of %v", days, OHLCDays)
+	}
+
+	var data [][]float64
+	url := fmt.Sprintf("%s/coins/%s/ohlc?vs_currency=usd&days=%d", c.baseURL, coinID, days)
+	if err := c.getJSON(ctx, url, &data); err != nil {
+		return nil, err
+	}
+
+	label, width := ohlcInterval(days)
+	candles := make([]domain.Candle, 0, len(data))
+	for _, point := range data {
+		if len(point) < 5 {
+			continue
+		}
+		candles = append(candles, domain.Candle{
+			Coin:     coinID,
+			Interval: label,
+			OpenTime: time.UnixMilli(int64(point[0])).UTC().Add(-width),
+			Open:     point[1],
+			High:     point[2],
+			Low:      point[3],
+			Close:    point[4],
+		})
+	}
+	return candles, nil
+}
+
+// Ensure CoinGeckoClient implements CandleFetcher
+var _ domain.CandleFetcher = (*CoinGeckoClient)(nil)
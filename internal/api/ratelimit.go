@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket shared by every request of the clients it is
+// given to, so loops such as backfills stay within the provider's budget.
+// It allows bursts of up to ten seconds' worth of requests.
+type RateLimiter struct {
+	mu        sync.Mutex
+	perMinute int
+	interval  time.Duration
+	burst     float64
+	tokens    float64
+	last      time.Time
+}
+
+// NewRateLimiter creates a limiter allowing perMinute requests per minute
+func NewRateLimiter(perMinute int) *RateLimiter {
+	perMinute = max(perMinute, 1)
+	burst := max(float64(perMinute)/6, 1)
+	return &RateLimiter{
+		perMinute: perMinute,
+		interval:  time.Minute / time.Duration(perMinute),
+		burst:     burst,
+		tokens:    burst,
+		last:      time.Now(),
+	}
+}
+
+// PerMinute returns the configured budget
+func (l *RateLimiter) PerMinute() int {
+	return l.perMinute
+}
+
+// Wait blocks until a request fits in the budget and returns how long it waited.
+// It fails at once with ErrBudgetExhausted if ctx would expire first.
+func (l *RateLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+float64(now.Sub(l.last))/float64(l.interval))
+	l.last = now
+	l.tokens--
+	wait := time.Duration(-l.tokens * float64(l.interval))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return 0, nil
+	}
+	if !fitsDeadline(ctx, wait) {
+		l.release()
+		return 0, fmt.Errorf("%w: %d/min leaves no room before the deadline", ErrBudgetExhausted, l.perMinute)
+	}
+	if err := sleep(ctx, wait); err != nil {
+		l.release()
+		return 0, err
+	}
+	return wait, nil
+}
+
+// release returns a token taken by a request that was never sent
+func (l *RateLimiter) release() {
+	l.mu.Lock()
+	l.tokens++
+	l.mu.Unlock()
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// drain takes every token of l's burst
+func drain(t *testing.T, l *RateLimiter) {
+	t.Helper()
+	for range int(l.burst) {
+		if waited, err := l.Wait(context.Background()); err != nil || waited != 0 {
+			t.Fatalf("Wait within the burst = %s, %v, want no delay", waited, err)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	// 600/min refills a token every 100ms and bursts up to 100 requests
+	l := NewRateLimiter(600)
+	drain(t, l)
+
+	start := time.Now()
+	waited, err := l.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if elapsed := time.Since(start); waited <= 0 || waited > 100*time.Millisecond || elapsed < waited {
+		t.Errorf("Wait after the burst returned %s after %s, want to wait up to 100ms", waited, elapsed)
+	}
+
+	// A deadline before the next token fails at once and gives the token back
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Wait(ctx); !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("Wait error = %v, want ErrBudgetExhausted", err)
+	}
+	if l.tokens < -1 {
+		t.Errorf("refused request kept its token: %v left", l.tokens)
+	}
+}
+
+func TestBudgetExhaustedIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	limiter := NewRateLimiter(6)
+	client := NewCoinGeckoClient(
+		WithBaseURL(srv.URL),
+		WithRateLimiter(limiter),
+		WithRetry(RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}),
+	)
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping within the budget: %v", err)
+	}
+
+	// The next token is ten seconds away
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := client.Ping(ctx)
+	if !errors.Is(err, ErrBudgetExhausted) || errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Ping error = %v, want ErrBudgetExhausted", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("server saw %d requests, want only the first", n)
+	}
+	if usage := client.Usage(); usage.Requests != 1 || usage.Retries != 0 {
+		t.Errorf("usage = %+v, want 1 request and no retries", usage)
+	}
+}
+
+func TestCoinGeckoAPIKey(t *testing.T) {
+	tests := []struct {
+		tier, header string
+	}{
+		{tier: TierFree},
+		{tier: TierDemo, header: "x-cg-demo-api-key"},
+		{tier: TierPro, header: "x-cg-pro-api-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tier, func(t *testing.T) {
+			var got http.Header
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+			}))
+			defer srv.Close()
+
+			client := NewCoinGeckoClient(WithBaseURL(srv.URL), WithAPIKey(tt.tier, "secret"))
+			if err := client.Ping(context.Background()); err != nil {
+				t.Fatalf("Ping: %v", err)
+			}
+			for _, h := range []string{"x-cg-demo-api-key", "x-cg-pro-api-key"} {
+				want := ""
+				if h == tt.header {
+					want = "secret"
+				}
+				if got.Get(h) != want {
+					t.Errorf("%s = %q, want %q", h, got.Get(h), want)
+				}
+			}
+		})
+	}
+
+	// Without an override a pro key goes to the pro API root
+	if got := NewCoinGeckoClient(WithAPIKey(TierPro, "secret")).baseURL; got != proBaseURL {
+		t.Errorf("pro base URL = %s, want %s", got, proBaseURL)
+	}
+	if got := NewCoinGeckoClient(WithAPIKey(TierDemo, "secret")).baseURL; got != baseURL {
+		t.Errorf("demo base URL = %s, want %s", got, baseURL)
+	}
+}
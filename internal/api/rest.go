@@ -0,0 +1,280 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	// defaultChunkSize keeps CoinGecko query strings well below URL length limits
+	defaultChunkSize   = 100
+	defaultConcurrency = 3
+	userAgent          = "go-crypto-bot"
+	// maxErrorBody bounds how much of an error response is kept in StatusError
+	maxErrorBody = 512
+)
+
+// Option configures an API client
+type Option func(*options)
+
+// options holds the settings shared by all API clients; each client uses the ones it supports
+type options struct {
+	baseURL    string
+	timeout    time.Duration
+	currencies []string
+	retry      RetryPolicy
+	limiter    *RateLimiter
+	transport  http.RoundTripper
+	tier       string
+	apiKey     string
+	// chunkSize and concurrency split large CoinGecko price requests
+	chunkSize   int
+	concurrency int
+	clock       domain.Clock
+}
+
+// WithTimeout sets the per-request HTTP timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithBaseURL points the client at a different API root, such as a local stand-in server
+func WithBaseURL(url string) Option {
+	return func(o *options) {
+		if url != "" {
+			o.baseURL = strings.TrimRight(url, "/")
+		}
+	}
+}
+
+// WithCurrencies sets the quote currencies requested in addition to USD.
+// Exchange clients that only quote USD-pegged pairs ignore it.
+func WithCurrencies(currencies ...string) Option {
+	return func(o *options) {
+		for _, cur := range currencies {
+			cur = strings.ToLower(cur)
+			if !slices.Contains(o.currencies, cur) {
+				o.currencies = append(o.currencies, cur)
+			}
+		}
+	}
+}
+
+// WithRateLimiter makes every request of the client wait for the shared limiter
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(o *options) {
+		o.limiter = l
+	}
+}
+
+// WithTransport sends requests through rt, e.g. to record or replay responses
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = rt
+	}
+}
+
+// WithClock sets the clock fetched prices are stamped with; the default is the system clock
+func WithClock(clock domain.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithAPIKey authenticates requests with a CoinGecko "demo" or "pro" key.
+// Other clients ignore it.
+func WithAPIKey(tier, key string) Option {
+	return func(o *options) {
+		o.tier = tier
+		o.apiKey = key
+	}
+}
+
+// WithChunking requests CoinGecko prices in chunks of size ids, running up to
+// concurrency requests at once; non-positive values keep the defaults
+func WithChunking(size, concurrency int) Option {
+	return func(o *options) {
+		if size > 0 {
+			o.chunkSize = size
+		}
+		if concurrency > 0 {
+			o.concurrency = concurrency
+		}
+	}
+}
+
+// newOptions applies opts; defaultBaseURL is used unless WithBaseURL set one
+func newOptions(defaultBaseURL string, opts []Option) options {
+	o := options{
+		timeout:     defaultTimeout,
+		currencies:  []string{domain.BaseCurrency},
+		retry:       DefaultRetryPolicy,
+		chunkSize:   defaultChunkSize,
+		concurrency: defaultConcurrency,
+		clock:       domain.SystemClock,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.baseURL == "" {
+		o.baseURL = defaultBaseURL
+	}
+	return o
+}
+
+// Usage counts the requests a client has sent
+type Usage struct {
+	// Requests is the number of HTTP requests sent, including retries
+	Requests int
+	Retries  int
+	// Throttled is the number of requests delayed by the rate limiter, and Waited their total delay
+	Throttled int
+	Waited    time.Duration
+}
+
+// usageCounter is the Usage shared by copies of a restClient
+type usageCounter struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+func (u *usageCounter) add(fn func(*Usage)) {
+	u.mu.Lock()
+	fn(&u.usage)
+	u.mu.Unlock()
+}
+
+// restClient performs JSON GET requests against a single API root
+type restClient struct {
+	httpClient *http.Client
+	baseURL    string
+	retry      RetryPolicy
+	limiter    *RateLimiter
+	header     http.Header
+	usage      *usageCounter
+	clock      domain.Clock
+}
+
+func newRESTClient(o options) restClient {
+	return restClient{
+		httpClient: &http.Client{
+			Timeout:   o.timeout,
+			Transport: o.transport,
+		},
+		baseURL: o.baseURL,
+		retry:   o.retry,
+		limiter: o.limiter,
+		header:  make(http.Header),
+		usage:   &usageCounter{},
+		clock:   o.clock,
+	}
+}
+
+// Usage returns the requests sent so far
+func (c *restClient) Usage() Usage {
+	c.usage.mu.Lock()
+	defer c.usage.mu.Unlock()
+	return c.usage.usage
+}
+
+// getJSON fetches url and decodes the JSON body into out; a nil out discards the body.
+// Failures that may be transient are retried according to the client's retry policy.
+func (c *restClient) getJSON(ctx context.Context, url string, out any) error {
+	return c.getCheckedJSON(ctx, url, out, nil)
+}
+
+// getCheckedJSON is getJSON for APIs that report errors in the body of a 200
+// response: check inspects each decoded body, and its error is retried like that
+// of a failed request
+func (c *restClient) getCheckedJSON(ctx context.Context, url string, out any, check func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := c.get(ctx, url, out)
+		if err == nil && check != nil {
+			err = check()
+		}
+		if err == nil || ctx.Err() != nil || attempt >= c.retry.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		delay := c.retry.backoff(attempt, err)
+		if !fitsDeadline(ctx, delay) {
+			return err
+		}
+		c.usage.add(func(u *Usage) { u.Retries++ })
+		log.Printf("Request to %s failed (attempt %d/%d): %v; retrying in %s",
+			redactQuery(url), attempt, c.retry.MaxAttempts, err, delay.Round(time.Millisecond))
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// get performs a single request once the rate limiter allows it
+func (c *restClient) get(ctx context.Context, url string, out any) error {
+	if c.limiter != nil {
+		waited, err := c.limiter.Wait(ctx)
+		if err != nil {
+			return err
+		}
+		if waited > 0 {
+			c.usage.add(func(u *Usage) {
+				u.Throttled++
+				u.Waited += waited
+			})
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	c.usage.add(func(u *Usage) { u.Requests++ })
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &NetworkError{Err: err}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &StatusError{
+			Code:       resp.StatusCode,
+			Status:     resp.Status,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(resp.Header, time.Now()),
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return &DecodeError{Err: err}
+	}
+	return nil
+}
+
+// redactQuery drops the query string so logs stay short
+func redactQuery(url string) string {
+	path, _, _ := strings.Cut(url, "?")
+	return path
+}
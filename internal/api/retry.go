@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. Rate limiting by the
+// server, server errors and network failures are retried; other errors, such as
+// refusals of the local RateLimiter, are returned at once.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first; 1 disables retries
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry; it doubles on every further retry
+	BaseDelay time.Duration
+	// MaxDelay caps a single backoff, including delays requested through Retry-After
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used by clients created without WithRetry
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   time.Second,
+	MaxDelay:    30 * time.Second,
+}
+
+// WithRetry sets the retry policy for failed requests
+func WithRetry(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = policy
+	}
+}
+
+// retryable reports whether a request that failed with err may succeed if repeated
+func retryable(err error) bool {
+	var netErr *NetworkError
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable) || errors.As(err, &netErr)
+}
+
+// backoff returns how long to wait before retry number attempt (starting at 1).
+// A Retry-After delay from the server wins over the exponential backoff, which
+// uses full jitter so that concurrent clients spread out.
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return min(statusErr.RetryAfter, p.MaxDelay)
+	}
+
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay) + 1
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// fitsDeadline reports whether ctx leaves enough time to wait d before retrying
+func fitsDeadline(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > d
+}
+
+// sleep waits for d unless ctx ends first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedResponse is a status code, optionally with a Retry-After header and a body
+type scriptedResponse struct {
+	code       int
+	retryAfter string
+	body       string
+}
+
+// scriptedServer answers the nth request with responses[n], repeating the last one,
+// and counts the requests
+func scriptedServer(t *testing.T, responses ...scriptedResponse) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		resp := responses[min(n, len(responses)-1)]
+		if resp.retryAfter != "" {
+			w.Header().Set("Retry-After", resp.retryAfter)
+		}
+		w.WriteHeader(resp.code)
+		body := resp.body
+		if body == "" && resp.code == http.StatusOK {
+			body = `{"ok":true}`
+		}
+		_, _ = fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestGetJSONRetries(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		responses []scriptedResponse
+		timeout   time.Duration
+		wantCalls int32
+		// wantErr is the sentinel the error must match; nil means success
+		wantErr error
+	}{
+		{
+			name:      "server error then success",
+			responses: []scriptedResponse{{code: 503}, {code: 200}},
+			wantCalls: 2,
+		},
+		{
+			name:      "rate limited then success",
+			responses: []scriptedResponse{{code: 429}, {code: 429}, {code: 200}},
+			wantCalls: 3,
+		},
+		{
+			name:      "gives up after max attempts",
+			responses: []scriptedResponse{{code: 500}},
+			wantCalls: 3,
+			wantErr:   ErrUnavailable,
+		},
+		{
+			name:      "client error is not retried",
+			responses: []scriptedResponse{{code: 404}},
+			wantCalls: 1,
+			wantErr:   ErrUnknownSymbol,
+		},
+		{
+			name:      "malformed body is not retried",
+			responses: []scriptedResponse{{code: 200, body: `{"ok":`}},
+			wantCalls: 1,
+			wantErr:   ErrDecode,
+		},
+		{
+			// Waiting out the server's delay would overrun the deadline
+			name:      "retry-after beyond the deadline",
+			responses: []scriptedResponse{{code: 429, retryAfter: "60"}, {code: 200}},
+			timeout:   time.Second,
+			wantCalls: 1,
+			wantErr:   ErrRateLimited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := scriptedServer(t, tt.responses...)
+			p := policy
+			if tt.timeout > 0 {
+				// Let the deadline, not MaxDelay, decide about long server delays
+				p.MaxDelay = time.Minute
+			}
+			client := newRESTClient(newOptions(srv.URL, []Option{WithRetry(p)}))
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			var out struct{ OK bool }
+			err := client.getJSON(ctx, srv.URL, &out)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("getJSON: %v", err)
+			case tt.wantErr == nil && !out.OK:
+				t.Error("response body was not decoded")
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("getJSON error = %v, want %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("sent %d requests, want %d", got, tt.wantCalls)
+			}
+			if usage := client.Usage(); int32(usage.Requests) != tt.wantCalls || int32(usage.Retries) != tt.wantCalls-1 {
+				t.Errorf("usage = %+v, want %d requests and %d retries", usage, tt.wantCalls, tt.wantCalls-1)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	srv, _ := scriptedServer(t, scriptedResponse{code: 429, retryAfter: "7"})
+	client := newRESTClient(newOptions(srv.URL, []Option{WithRetry(RetryPolicy{MaxAttempts: 1})}))
+
+	err := client.getJSON(context.Background(), srv.URL, nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.RetryAfter != 7*time.Second {
+		t.Fatalf("getJSON error = %#v, want a StatusError asking to retry after 7s", err)
+	}
+
+	// The server's delay replaces the backoff, up to MaxDelay
+	if got := (RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: time.Minute}).backoff(1, err); got != 7*time.Second {
+		t.Errorf("backoff = %s, want the requested 7s", got)
+	}
+	if got := (RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}).backoff(1, err); got != 2*time.Second {
+		t.Errorf("backoff = %s, want it capped at 2s", got)
+	}
+
+	now := time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)
+	headers := map[string]time.Duration{
+		"":     0,
+		"3":    3 * time.Second,
+		"-1":   0,
+		"soon": 0,
+		now.Add(90 * time.Second).Format(http.TimeFormat): 90 * time.Second,
+		now.Add(-time.Minute).Format(http.TimeFormat):     0,
+	}
+	for value, want := range headers {
+		h := http.Header{}
+		if value != "" {
+			h.Set("Retry-After", value)
+		}
+		if got := parseRetryAfter(h, now); got != want {
+			t.Errorf("Retry-After %q = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	err := &StatusError{Code: http.StatusServiceUnavailable}
+
+	// Full jitter keeps each delay within the doubled ceiling
+	ceilings := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, ceiling := range ceilings {
+		for range 50 {
+			if got := p.backoff(i+1, err); got <= 0 || got > ceiling {
+				t.Fatalf("backoff(%d) = %s, want within (0, %s]", i+1, got, ceiling)
+			}
+		}
+	}
+	// A shift past the width of time.Duration still yields MaxDelay at most
+	if got := p.backoff(80, err); got <= 0 || got > p.MaxDelay {
+		t.Errorf("backoff(80) = %s, want within (0, %s]", got, p.MaxDelay)
+	}
+}
@@ -0,0 +1,745 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/api"
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+	"github.com/viczuno/go-crypto-bot/internal/scheduler"
+)
+
+// DefaultPath is the config file looked up when no path is given
+const DefaultPath = "./config.json"
+
+// Environment variables that override values from the config file
+const (
+	EnvConfigPath      = "CRYPTO_BOT_CONFIG"
+	EnvCoins           = "CRYPTO_BOT_COINS"
+	EnvDBPath          = "CRYPTO_BOT_DB_PATH"
+	EnvReadmePath      = "CRYPTO_BOT_README_PATH"
+	EnvHugoDataPath    = "CRYPTO_BOT_HUGO_DATA_PATH"
+	EnvHugoHistoryPath = "CRYPTO_BOT_HUGO_HISTORY_PATH"
+	EnvHugoCandlesPath = "CRYPTO_BOT_HUGO_CANDLES_PATH"
+	EnvHistoryDays     = "CRYPTO_BOT_HISTORY_DAYS"
+	EnvTimeout         = "CRYPTO_BOT_TIMEOUT"
+	EnvRequestTimeout  = "CRYPTO_BOT_REQUEST_TIMEOUT"
+	EnvCurrencies      = "CRYPTO_BOT_CURRENCIES"
+	EnvCoinGeckoTier   = "CRYPTO_BOT_COINGECKO_TIER"
+	// EnvCoinGeckoKey and EnvCoinGeckoKeyFile are the only ways to pass the CoinGecko API key
+	EnvCoinGeckoKey     = "COINGECKO_API_KEY"
+	EnvCoinGeckoKeyFile = "CRYPTO_BOT_COINGECKO_KEY_FILE"
+)
+
+// Config holds all runtime settings for the bot
+type Config struct {
+	Coins []domain.CoinMetadata `json:"coins"`
+	// Tracking selects whether Coins or the current top N by market cap are tracked
+	Tracking Tracking `json:"tracking"`
+	// Currencies lists the quote currencies fetched on every run; USD is always included
+	Currencies []string `json:"currencies"`
+	// Providers lists the price sources to try, in order of preference
+	Providers []string `json:"providers"`
+	// Aggregation selects how prices from several providers are combined
+	Aggregation Aggregation `json:"aggregation"`
+	// OnMissing decides what happens to coins no provider could price:
+	// "fail" the run, reuse the "stale" last stored price, or "drop" the coin (default)
+	OnMissing string    `json:"on_missing"`
+	CoinGecko CoinGecko `json:"coingecko"`
+	Binance   Exchange  `json:"binance"`
+	Kraken    Exchange  `json:"kraken"`
+	Coinbase  Exchange  `json:"coinbase"`
+	Report    Report    `json:"report"`
+	Paths     Paths     `json:"paths"`
+	History   History   `json:"history"`
+	Retention Retention `json:"retention"`
+	Timeout   Timeouts  `json:"timeout"`
+	Retry     Retry     `json:"retry"`
+	Daemon    Daemon    `json:"daemon"`
+	// Fixtures records API responses to, or replays them from, a directory
+	Fixtures Fixtures `json:"fixtures"`
+	// AsOf, set by --as-of, rebuilds reports from the history stored up to that moment
+	AsOf time.Time `json:"-"`
+}
+
+// Paths contains the input and output file locations
+type Paths struct {
+	DB          string `json:"db"`
+	Readme      string `json:"readme"`
+	HugoData    string `json:"hugo_data"`
+	HugoHistory string `json:"hugo_history"`
+	// HugoCandles is the directory for the per-coin OHLC candle files
+	HugoCandles string `json:"hugo_candles"`
+}
+
+// CoinGecko contains the CoinGecko API plan settings
+type CoinGecko struct {
+	// BaseURL overrides the API root, e.g. for a local stand-in server
+	BaseURL string `json:"base_url"`
+	// Tier is the API plan: "free" (default), "demo" or "pro"; demo and pro need an API key
+	Tier string `json:"tier"`
+	// APIKey is read from $COINGECKO_API_KEY or KeyFile, never from the config file
+	APIKey Secret `json:"-"`
+	// KeyFile is a file holding the API key, such as a mounted secret
+	KeyFile string `json:"key_file"`
+	// RequestsPerMinute is the budget shared by all CoinGecko calls; zero uses the tier's limit
+	RequestsPerMinute int `json:"requests_per_minute"`
+	// ChunkSize is the number of coin ids per price request
+	ChunkSize int `json:"chunk_size"`
+	// Concurrency is the number of price requests in flight at once
+	Concurrency int `json:"concurrency"`
+}
+
+// CoinGeckoTiers lists the supported CoinGecko API plans
+var CoinGeckoTiers = []string{"free", "demo", "pro"}
+
+// Secret is a string that is never printed or serialized
+type Secret string
+
+// String hides the value
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+// GoString hides the value from %#v
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// MarshalJSON hides the value
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Exchange contains settings for an exchange ticker provider
+type Exchange struct {
+	// BaseURL overrides the API root, e.g. for a local stand-in server
+	BaseURL string `json:"base_url"`
+	// Symbols maps coin ids to the exchange's trading pairs,
+	// e.g. "BTCUSDT" on Binance, "XXBTZUSD" on Kraken or "BTC-USD" on Coinbase
+	Symbols map[string]string `json:"symbols"`
+}
+
+// Tracking selects the tracked coin universe
+type Tracking struct {
+	// Mode is "config" (the coins list, default) or "top" (the TopN largest coins by market cap)
+	Mode string `json:"mode"`
+	TopN int    `json:"top_n"`
+}
+
+// Tracking modes
+const (
+	TrackConfig = "config"
+	TrackTop    = "top"
+)
+
+// Aggregation contains the settings for combining provider prices
+type Aggregation struct {
+	// Mode is "fallback" (first provider that has a coin wins) or "median"
+	Mode string `json:"mode"`
+	// TolerancePct rejects quotes further than this percentage from the median
+	TolerancePct float64 `json:"tolerance_pct"`
+	// Quorum is the number of agreeing providers required to price a coin
+	Quorum int `json:"quorum"`
+}
+
+// Aggregation modes
+const (
+	AggregateFallback = "fallback"
+	AggregateMedian   = "median"
+)
+
+// Report contains README rendering options
+type Report struct {
+	// Currencies selects the price columns shown in the README, in order
+	Currencies []string `json:"currencies"`
+	// SortBy orders the README table: "config" (default) or "market_cap"
+	SortBy string `json:"sort_by"`
+	// StaleAfter flags provider quotes older than this; zero disables the check
+	StaleAfter Duration `json:"stale_after"`
+	// MaxSpreadPct flags coins whose provider quotes differ by more than this percentage; zero disables the check
+	MaxSpreadPct float64 `json:"max_spread_pct"`
+}
+
+// Report sort orders
+const (
+	SortByConfig    = "config"
+	SortByMarketCap = "market_cap"
+)
+
+// History contains the history windows used for exports
+type History struct {
+	Days int `json:"days"`
+	// CandleDays is the range of OHLC candles fetched and exported, one of
+	// api.OHLCDays; CoinGecko picks the candle width from it
+	CandleDays int `json:"candle_days"`
+	// Resolution of the exported history: "auto" picks one from Days, or raw, 1h, 1d or 1w
+	Resolution string `json:"resolution"`
+}
+
+// HistoryAuto picks the export resolution from the length of the history
+const HistoryAuto = "auto"
+
+// ExportResolution returns the resolution history is exported at, resolving
+// "auto" to the coarsest one that still charts Days in detail
+func (h History) ExportResolution() domain.Resolution {
+	if h.Resolution == HistoryAuto {
+		return domain.ResolutionFor(time.Duration(h.Days) * 24 * time.Hour)
+	}
+	return domain.Resolution(h.Resolution)
+}
+
+// Retention limits how long db compact keeps stored history; zero keeps it forever
+type Retention struct {
+	// RawDays keeps the raw price samples of this many days
+	RawDays int `json:"raw_days"`
+	// RollupDays keeps the hourly, daily and weekly rollups of this many days; at least RawDays
+	RollupDays int `json:"rollup_days"`
+}
+
+// Timeouts contains the run and per-request time limits
+type Timeouts struct {
+	Run     Duration `json:"run"`
+	Request Duration `json:"request"`
+}
+
+// Fixtures contains the HTTP record/replay directories; at most one may be set
+type Fixtures struct {
+	// Record saves every API response to this directory
+	Record string `json:"record"`
+	// Replay answers API requests from responses recorded in this directory, without network access
+	Replay string `json:"replay"`
+}
+
+// Retry contains the backoff settings for failed API requests
+type Retry struct {
+	// MaxAttempts is the total number of tries per request; 1 disables retries
+	MaxAttempts int `json:"max_attempts"`
+	// BaseDelay is the first backoff, doubled on each further retry
+	BaseDelay Duration `json:"base_delay"`
+	// MaxDelay caps a single backoff, including server-requested Retry-After delays
+	MaxDelay Duration `json:"max_delay"`
+}
+
+// Daemon contains the job schedule for long-running mode
+type Daemon struct {
+	StatePath string      `json:"state_path"`
+	Jobs      []DaemonJob `json:"jobs"`
+}
+
+// DaemonJob schedules one of the stages in DaemonJobNames
+type DaemonJob struct {
+	Name     string   `json:"name"`
+	Schedule string   `json:"schedule"`
+	Jitter   Duration `json:"jitter"`
+}
+
+// MissingPolicies lists the accepted on_missing values
+var MissingPolicies = []string{"fail", "stale", "drop"}
+
+// ProviderNames lists the supported price providers
+var ProviderNames = []string{"coingecko", "binance", "kraken", "coinbase"}
+
+// DaemonJobNames lists the stages that can be scheduled in daemon mode
+var DaemonJobNames = []string{"run", "fetch", "report", "export", "candles", "compact"}
+
+// Duration is a time.Duration that decodes from strings like "5m" or "30s"
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON formats the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Std returns the value as a time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		Coins:      domain.DefaultCoins(),
+		Currencies: []string{domain.BaseCurrency},
+		Providers:  []string{"coingecko"},
+		OnMissing:  "drop",
+		Tracking: Tracking{
+			Mode: TrackConfig,
+			TopN: 20,
+		},
+		CoinGecko: CoinGecko{
+			Tier:        "free",
+			ChunkSize:   100,
+			Concurrency: 3,
+		},
+		Aggregation: Aggregation{
+			Mode:         AggregateFallback,
+			TolerancePct: 2,
+			Quorum:       2,
+		},
+		Binance: Exchange{
+			Symbols: map[string]string{
+				"bitcoin":  "BTCUSDT",
+				"ethereum": "ETHUSDT",
+				"solana":   "SOLUSDT",
+				"cardano":  "ADAUSDT",
+				"polkadot": "DOTUSDT",
+			},
+		},
+		Kraken: Exchange{
+			Symbols: map[string]string{
+				"bitcoin":  "XXBTZUSD",
+				"ethereum": "XETHZUSD",
+				"solana":   "SOLUSD",
+				"cardano":  "ADAUSD",
+				"polkadot": "DOTUSD",
+			},
+		},
+		Coinbase: Exchange{
+			Symbols: map[string]string{
+				"bitcoin":  "BTC-USD",
+				"ethereum": "ETH-USD",
+				"solana":   "SOL-USD",
+				"cardano":  "ADA-USD",
+				"polkadot": "DOT-USD",
+			},
+		},
+		Report: Report{
+			Currencies:   []string{domain.BaseCurrency},
+			SortBy:       SortByConfig,
+			StaleAfter:   Duration(time.Hour),
+			MaxSpreadPct: 1,
+		},
+		Paths: Paths{
+			DB:          "./crypto_history.db",
+			Readme:      "./README.md",
+			HugoData:    "./data/crypto.json",
+			HugoHistory: "./data/history",
+			HugoCandles: "./data/candles",
+		},
+		History: History{
+			Days:       30,
+			CandleDays: 30,
+			Resolution: HistoryAuto,
+		},
+		Timeout: Timeouts{
+			Run:     Duration(5 * time.Minute),
+			Request: Duration(30 * time.Second),
+		},
+		Retry: Retry{
+			MaxAttempts: 4,
+			BaseDelay:   Duration(time.Second),
+			MaxDelay:    Duration(30 * time.Second),
+		},
+		Daemon: Daemon{
+			StatePath: "./daemon_state.json",
+			Jobs: []DaemonJob{
+				{Name: "run", Schedule: "0 0,12 * * *"},
+			},
+		},
+	}
+}
+
+// Load reads the config file at path, applies environment overrides and validates the result.
+// An empty path falls back to $CRYPTO_BOT_CONFIG and then DefaultPath; a missing
+// DefaultPath is not an error and yields the built-in defaults.
+func Load(path string) (*Config, error) {
+	explicit := true
+	if path == "" {
+		path = os.Getenv(EnvConfigPath)
+	}
+	if path == "" {
+		path = DefaultPath
+		explicit = false
+	}
+
+	cfg := Default()
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		// Coins from the file replace the defaults rather than merging with them
+		cfg.Coins = nil
+		// A misspelt key would otherwise silently keep its default
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+	default:
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.loadSecrets(); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Coins) == 0 {
+		cfg.Coins = domain.DefaultCoins()
+	}
+	cfg.normalizeCurrencies()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) applyEnv() error {
+	if v := os.Getenv(EnvCoins); v != "" {
+		coins, err := ParseCoins(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", EnvCoins, err)
+		}
+		c.Coins = coins
+	}
+
+	if v := os.Getenv(EnvCurrencies); v != "" {
+		c.Currencies = strings.Split(v, ",")
+	}
+
+	setString(&c.CoinGecko.Tier, EnvCoinGeckoTier)
+	setString(&c.CoinGecko.KeyFile, EnvCoinGeckoKeyFile)
+	if v := os.Getenv(EnvCoinGeckoKey); v != "" {
+		c.CoinGecko.APIKey = Secret(strings.TrimSpace(v))
+	}
+
+	setString(&c.Paths.DB, EnvDBPath)
+	setString(&c.Paths.Readme, EnvReadmePath)
+	setString(&c.Paths.HugoData, EnvHugoDataPath)
+	setString(&c.Paths.HugoHistory, EnvHugoHistoryPath)
+	setString(&c.Paths.HugoCandles, EnvHugoCandlesPath)
+
+	if err := setInt(&c.History.Days, EnvHistoryDays); err != nil {
+		return err
+	}
+	if err := setDuration(&c.Timeout.Run, EnvTimeout); err != nil {
+		return err
+	}
+	return setDuration(&c.Timeout.Request, EnvRequestTimeout)
+}
+
+// normalizeCurrencies lower-cases currency codes and makes sure USD is always fetched
+func (c *Config) normalizeCurrencies() {
+	normalize := func(list []string) []string {
+		out := make([]string, 0, len(list))
+		for _, cur := range list {
+			cur = strings.ToLower(strings.TrimSpace(cur))
+			if !slices.Contains(out, cur) {
+				out = append(out, cur)
+			}
+		}
+		return out
+	}
+
+	c.Currencies = normalize(c.Currencies)
+	if !slices.Contains(c.Currencies, domain.BaseCurrency) {
+		c.Currencies = append([]string{domain.BaseCurrency}, c.Currencies...)
+	}
+	c.Report.Currencies = normalize(c.Report.Currencies)
+	if len(c.Report.Currencies) == 0 {
+		c.Report.Currencies = []string{domain.BaseCurrency}
+	}
+}
+
+// ParseCoins parses a comma-separated list of id:Name:SYMBOL entries
+func ParseCoins(s string) ([]domain.CoinMetadata, error) {
+	var coins []domain.CoinMetadata
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("coin %q must have the form id:Name:SYMBOL", entry)
+		}
+		coins = append(coins, domain.CoinMetadata{
+			ID:     strings.TrimSpace(parts[0]),
+			Name:   strings.TrimSpace(parts[1]),
+			Symbol: strings.TrimSpace(parts[2]),
+		})
+	}
+	return coins, nil
+}
+
+// Validate checks the configuration for missing or inconsistent values
+func (c *Config) Validate() error {
+	var errs []error
+
+	seen := make(map[string]bool, len(c.Coins))
+	for i, coin := range c.Coins {
+		switch {
+		case coin.ID == "":
+			errs = append(errs, fmt.Errorf("coins[%d]: empty id", i))
+		case seen[coin.ID]:
+			errs = append(errs, fmt.Errorf("coins[%d]: duplicate id %q", i, coin.ID))
+		}
+		if coin.Name == "" {
+			errs = append(errs, fmt.Errorf("coins[%d] (%s): empty name", i, coin.ID))
+		}
+		if coin.Symbol == "" {
+			errs = append(errs, fmt.Errorf("coins[%d] (%s): empty symbol", i, coin.ID))
+		}
+		seen[coin.ID] = true
+	}
+
+	for i, cur := range c.Currencies {
+		if cur == "" {
+			errs = append(errs, fmt.Errorf("currencies[%d]: empty currency code", i))
+		}
+	}
+	for i, cur := range c.Report.Currencies {
+		if !slices.Contains(c.Currencies, cur) {
+			errs = append(errs, fmt.Errorf("report.currencies[%d]: %q is not listed in currencies", i, cur))
+		}
+	}
+
+	if len(c.Providers) == 0 {
+		errs = append(errs, errors.New("providers: at least one provider is required"))
+	}
+	providers := make(map[string]bool, len(c.Providers))
+	for i, p := range c.Providers {
+		switch {
+		case !slices.Contains(ProviderNames, p):
+			errs = append(errs, fmt.Errorf("providers[%d]: unknown provider %q, want one of %s", i, p, strings.Join(ProviderNames, ", ")))
+		case providers[p]:
+			errs = append(errs, fmt.Errorf("providers[%d]: duplicate provider %q", i, p))
+		}
+		providers[p] = true
+	}
+
+	if others := c.otherCurrencies(); len(others) > 0 && !providers["coingecko"] {
+		errs = append(errs, fmt.Errorf("currencies: %s need the coingecko provider; the exchanges quote USD only", strings.Join(others, ", ")))
+	}
+
+	exchanges := map[string]Exchange{"binance": c.Binance, "kraken": c.Kraken, "coinbase": c.Coinbase}
+	for _, name := range []string{"binance", "kraken", "coinbase"} {
+		for id, symbol := range exchanges[name].Symbols {
+			if symbol == "" {
+				errs = append(errs, fmt.Errorf("%s.symbols[%s]: empty trading pair", name, id))
+			}
+		}
+	}
+
+	switch c.Tracking.Mode {
+	case TrackConfig:
+	case TrackTop:
+		if c.Tracking.TopN < 1 || c.Tracking.TopN > 1000 {
+			errs = append(errs, fmt.Errorf("tracking.top_n: must be between 1 and 1000, got %d", c.Tracking.TopN))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tracking.mode: want %q or %q, got %q", TrackConfig, TrackTop, c.Tracking.Mode))
+	}
+
+	switch {
+	case !slices.Contains(CoinGeckoTiers, c.CoinGecko.Tier):
+		errs = append(errs, fmt.Errorf("coingecko.tier: unknown tier %q, want one of %s", c.CoinGecko.Tier, strings.Join(CoinGeckoTiers, ", ")))
+	case c.CoinGecko.Tier != "free" && c.CoinGecko.APIKey == "":
+		errs = append(errs, fmt.Errorf("coingecko.tier: the %s tier needs an API key in $%s or coingecko.key_file", c.CoinGecko.Tier, EnvCoinGeckoKey))
+	}
+	if c.CoinGecko.RequestsPerMinute < 0 {
+		errs = append(errs, errors.New("coingecko.requests_per_minute: must not be negative"))
+	}
+	if c.CoinGecko.ChunkSize < 1 {
+		errs = append(errs, fmt.Errorf("coingecko.chunk_size: must be positive, got %d", c.CoinGecko.ChunkSize))
+	}
+	if c.CoinGecko.Concurrency < 1 {
+		errs = append(errs, fmt.Errorf("coingecko.concurrency: must be positive, got %d", c.CoinGecko.Concurrency))
+	}
+
+	if !slices.Contains(MissingPolicies, c.OnMissing) {
+		errs = append(errs, fmt.Errorf("on_missing: want one of %s, got %q", strings.Join(MissingPolicies, ", "), c.OnMissing))
+	}
+
+	switch c.Aggregation.Mode {
+	case AggregateFallback:
+	case AggregateMedian:
+		if c.Aggregation.TolerancePct <= 0 {
+			errs = append(errs, errors.New("aggregation.tolerance_pct: must be positive"))
+		}
+		if c.Aggregation.Quorum < 1 || c.Aggregation.Quorum > len(c.Providers) {
+			errs = append(errs, fmt.Errorf("aggregation.quorum: must be between 1 and the number of providers (%d), got %d", len(c.Providers), c.Aggregation.Quorum))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("aggregation.mode: want %q or %q, got %q", AggregateFallback, AggregateMedian, c.Aggregation.Mode))
+	}
+
+	switch c.Report.SortBy {
+	case SortByConfig, SortByMarketCap:
+	default:
+		errs = append(errs, fmt.Errorf("report.sort_by: want %q or %q, got %q", SortByConfig, SortByMarketCap, c.Report.SortBy))
+	}
+	if c.Report.StaleAfter < 0 {
+		errs = append(errs, errors.New("report.stale_after: must not be negative"))
+	}
+	if c.Report.MaxSpreadPct < 0 {
+		errs = append(errs, errors.New("report.max_spread_pct: must not be negative"))
+	}
+
+	if c.Paths.DB == "" {
+		errs = append(errs, errors.New("paths.db: must not be empty"))
+	}
+	if c.Paths.Readme == "" {
+		errs = append(errs, errors.New("paths.readme: must not be empty"))
+	}
+	if c.Paths.HugoData == "" {
+		errs = append(errs, errors.New("paths.hugo_data: must not be empty"))
+	}
+	if c.Paths.HugoHistory == "" {
+		errs = append(errs, errors.New("paths.hugo_history: must not be empty"))
+	}
+	if c.Paths.HugoCandles == "" {
+		errs = append(errs, errors.New("paths.hugo_candles: must not be empty"))
+	}
+	if c.History.Days <= 0 {
+		errs = append(errs, fmt.Errorf("history.days: must be positive, got %d", c.History.Days))
+	}
+	if !slices.Contains(api.OHLCDays, c.History.CandleDays) {
+		errs = append(errs, fmt.Errorf("history.candle_days: must be one of %v, got %d", api.OHLCDays, c.History.CandleDays))
+	}
+	if c.History.Resolution != HistoryAuto {
+		if _, err := domain.ParseResolution(c.History.Resolution); err != nil {
+			errs = append(errs, fmt.Errorf("history.resolution: want %q, raw, 1h, 1d or 1w, got %q", HistoryAuto, c.History.Resolution))
+		}
+	}
+	if c.Retention.RawDays < 0 {
+		errs = append(errs, fmt.Errorf("retention.raw_days: must not be negative, got %d", c.Retention.RawDays))
+	} else if c.Retention.RawDays > 0 && c.Retention.RawDays < c.History.Days && c.History.ExportResolution() == domain.ResolutionRaw {
+		errs = append(errs, fmt.Errorf("retention.raw_days: must cover the %d raw history days exported, got %d", c.History.Days, c.Retention.RawDays))
+	}
+	if c.Retention.RollupDays < 0 {
+		errs = append(errs, fmt.Errorf("retention.rollup_days: must not be negative, got %d", c.Retention.RollupDays))
+	} else if c.Retention.RollupDays > 0 && (c.Retention.RawDays == 0 || c.Retention.RollupDays < c.Retention.RawDays) {
+		errs = append(errs, fmt.Errorf("retention.rollup_days: must keep rollups at least as long as raw_days, got %d", c.Retention.RollupDays))
+	}
+	if c.Timeout.Run <= 0 {
+		errs = append(errs, errors.New("timeout.run: must be positive"))
+	}
+	if c.Timeout.Request <= 0 {
+		errs = append(errs, errors.New("timeout.request: must be positive"))
+	}
+	if c.Retry.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("retry.max_attempts: must be at least 1, got %d", c.Retry.MaxAttempts))
+	}
+	if c.Retry.BaseDelay < 0 || c.Retry.MaxDelay < 0 {
+		errs = append(errs, errors.New("retry: delays must not be negative"))
+	}
+	if c.Retry.BaseDelay > c.Retry.MaxDelay {
+		errs = append(errs, errors.New("retry.base_delay: must not exceed retry.max_delay"))
+	}
+
+	if c.Fixtures.Record != "" && c.Fixtures.Replay != "" {
+		errs = append(errs, errors.New("fixtures: record and replay are mutually exclusive"))
+	}
+
+	jobs := make(map[string]bool, len(c.Daemon.Jobs))
+	for i, job := range c.Daemon.Jobs {
+		switch {
+		case !slices.Contains(DaemonJobNames, job.Name):
+			errs = append(errs, fmt.Errorf("daemon.jobs[%d]: unknown job %q, want one of %s", i, job.Name, strings.Join(DaemonJobNames, ", ")))
+		case jobs[job.Name]:
+			errs = append(errs, fmt.Errorf("daemon.jobs[%d]: duplicate job %q", i, job.Name))
+		}
+		jobs[job.Name] = true
+		if _, err := scheduler.Parse(job.Schedule); err != nil {
+			errs = append(errs, fmt.Errorf("daemon.jobs[%d] (%s): %w", i, job.Name, err))
+		}
+		if job.Jitter < 0 {
+			errs = append(errs, fmt.Errorf("daemon.jobs[%d] (%s): jitter must not be negative", i, job.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Warnings describes settings that are valid but may not do what was meant
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if others := c.otherCurrencies(); len(others) > 0 {
+		for _, p := range c.Providers {
+			if p != "coingecko" {
+				warnings = append(warnings, fmt.Sprintf("providers: %s quotes USD only, so the prices it supplies have no %s", p, strings.Join(others, ", ")))
+			}
+		}
+	}
+	return warnings
+}
+
+// otherCurrencies returns the currencies fetched besides USD
+func (c *Config) otherCurrencies() []string {
+	return slices.DeleteFunc(slices.Clone(c.Currencies), func(cur string) bool { return cur == domain.BaseCurrency })
+}
+
+// loadSecrets reads the CoinGecko API key from KeyFile unless the environment provided one
+func (c *Config) loadSecrets() error {
+	if c.CoinGecko.APIKey != "" || c.CoinGecko.KeyFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.CoinGecko.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read coingecko.key_file: %w", err)
+	}
+	c.CoinGecko.APIKey = Secret(strings.TrimSpace(string(data)))
+	return nil
+}
+
+func setString(dst *string, env string) {
+	if v := os.Getenv(env); v != "" {
+		*dst = v
+	}
+}
+
+func setInt(dst *int, env string) error {
+	v := os.Getenv(env)
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", env, err)
+	}
+	*dst = n
+	return nil
+}
+
+func setDuration(dst *Duration, env string) error {
+	v := os.Getenv(env)
+	if v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", env, err)
+	}
+	*dst = Duration(d)
+	return nil
+}
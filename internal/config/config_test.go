@@ -0,0 +1,199 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// writeConfig writes body to a config file in a temporary directory and returns its path
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		env  map[string]string
+		// wantErr is a substring of the expected error; empty means Load succeeds
+		wantErr string
+		check   func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "file values",
+			body: `{"coins":[{"id":"bitcoin","name":"Bitcoin","symbol":"BTC"}],"currencies":["EUR"],"paths":{"db":"bot.db"}}`,
+			check: func(t *testing.T, cfg *Config) {
+				if len(cfg.Coins) != 1 || cfg.Coins[0].ID != "bitcoin" {
+					t.Errorf("coins = %v, want only bitcoin", cfg.Coins)
+				}
+				if !slices.Equal(cfg.Currencies, []string{domain.BaseCurrency, "eur"}) {
+					t.Errorf("currencies = %v, want usd added before eur", cfg.Currencies)
+				}
+				if cfg.Paths.DB != "bot.db" || cfg.Paths.Readme != Default().Paths.Readme {
+					t.Errorf("paths = %+v, want bot.db and the default README", cfg.Paths)
+				}
+			},
+		},
+		{
+			name:    "unknown key",
+			body:    `{"database":"bot.db"}`,
+			wantErr: `unknown field "database"`,
+		},
+		{
+			name:    "misspelt nested key",
+			body:    `{"history":{"day":7}}`,
+			wantErr: `unknown field "day"`,
+		},
+		{
+			name: "environment overrides the file",
+			body: `{"paths":{"db":"bot.db"},"history":{"days":7}}`,
+			env: map[string]string{
+				EnvCoins:       "ethereum:Ethereum:ETH, solana:Solana:SOL",
+				EnvCurrencies:  "usd,GBP",
+				EnvDBPath:      "env.db",
+				EnvHistoryDays: "14",
+				EnvTimeout:     "90s",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if len(cfg.Coins) != 2 || cfg.Coins[1].Symbol != "SOL" {
+					t.Errorf("coins = %v, want ethereum and solana", cfg.Coins)
+				}
+				if !slices.Equal(cfg.Currencies, []string{domain.BaseCurrency, "gbp"}) {
+					t.Errorf("currencies = %v, want usd and gbp", cfg.Currencies)
+				}
+				if cfg.Paths.DB != "env.db" || cfg.History.Days != 14 || cfg.Timeout.Run.Std() != 90*time.Second {
+					t.Errorf("db %q, %d days and %s run timeout, want env.db, 14 and 1m30s", cfg.Paths.DB, cfg.History.Days, cfg.Timeout.Run.Std())
+				}
+			},
+		},
+		{
+			name:    "malformed environment number",
+			body:    `{}`,
+			env:     map[string]string{EnvHistoryDays: "two weeks"},
+			wantErr: EnvHistoryDays,
+		},
+		{
+			name:    "malformed environment coin",
+			body:    `{}`,
+			env:     map[string]string{EnvCoins: "bitcoin:BTC"},
+			wantErr: EnvCoins,
+		},
+		{
+			name:    "environment fails validation",
+			body:    `{}`,
+			env:     map[string]string{EnvTimeout: "-1s"},
+			wantErr: "timeout.run",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			cfg, err := Load(writeConfig(t, tt.body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load error = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *Config)
+		// wantErr is a substring of the expected error; empty means the config is valid
+		wantErr string
+	}{
+		{name: "defaults", mutate: func(c *Config) {}},
+		{
+			name: "duplicate coin id",
+			mutate: func(c *Config) {
+				c.Coins = append(c.Coins, domain.CoinMetadata{ID: "bitcoin", Name: "Bitcoin", Symbol: "BTC"})
+			},
+			wantErr: `duplicate id "bitcoin"`,
+		},
+		{
+			name:    "empty symbol",
+			mutate:  func(c *Config) { c.Coins[0].Symbol = "" },
+			wantErr: "empty symbol",
+		},
+		{
+			name:    "unknown provider",
+			mutate:  func(c *Config) { c.Providers = []string{"coingecko", "bitstamp"} },
+			wantErr: `unknown provider "bitstamp"`,
+		},
+		{
+			name:    "empty currency",
+			mutate:  func(c *Config) { c.Currencies = []string{domain.BaseCurrency, ""} },
+			wantErr: "empty currency code",
+		},
+		{
+			name:    "report currency not fetched",
+			mutate:  func(c *Config) { c.Report.Currencies = []string{"eur"} },
+			wantErr: `"eur" is not listed in currencies`,
+		},
+		{
+			name: "other currencies without coingecko",
+			mutate: func(c *Config) {
+				c.Currencies = []string{domain.BaseCurrency, "eur", "gbp"}
+				c.Providers = []string{"binance", "kraken"}
+			},
+			wantErr: "eur, gbp need the coingecko provider",
+		},
+		{
+			name: "other currencies with an exchange fallback",
+			mutate: func(c *Config) {
+				c.Currencies = []string{domain.BaseCurrency, "eur"}
+				c.Providers = []string{"coingecko", "binance"}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	cfg := Default()
+	if w := cfg.Warnings(); len(w) != 0 {
+		t.Errorf("defaults warn %v, want nothing", w)
+	}
+
+	cfg.Currencies = []string{domain.BaseCurrency, "eur"}
+	cfg.Providers = []string{"coingecko", "binance"}
+	w := cfg.Warnings()
+	if len(w) != 1 || !strings.Contains(w[0], "binance") || !strings.Contains(w[0], "eur") {
+		t.Errorf("Warnings = %v, want one about binance lacking eur", w)
+	}
+}
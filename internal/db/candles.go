@@ -0,0 +1,91 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// SaveCandles stores candles keyed by coin, interval and open time (Unix milliseconds).
+// A candle that is already stored is overwritten, so the still-open latest candle
+// is corrected on the next fetch.
+func (r *SQLiteRepository) SaveCandles(candles []domain.Candle) error {
+	tx, err := r.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO candles (coin, interval, open_time, open, high, low, close)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(coin, interval, open_time) DO UPDATE SET
+			open = excluded.open,
+			high = excluded.high,
+			low = excluded.low,
+			close = excluded.close
+	`)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, c := range candles {
+		if _, err := stmt.Exec(c.Coin, c.Interval, c.OpenTime.UnixMilli(), c.Open, c.High, c.Low, c.Close); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to save candle for %s: %w", c.Coin, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetCandles retrieves the candles of a coin and interval opened between from and to, oldest first
+func (r *SQLiteRepository) GetCandles(coinID, interval string, from, to time.Time) ([]domain.Candle, error) {
+	query := `
+		SELECT open_time, open, high, low, close
+		FROM candles
+		WHERE coin = ? AND interval = ? AND open_time BETWEEN ? AND ?
+		ORDER BY open_time ASC
+	`
+	rows, err := r.conn.Query(query, coinID, interval, from.UnixMilli(), to.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query candles: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var candles []domain.Candle
+	for rows.Next() {
+		c := domain.Candle{Coin: coinID, Interval: interval}
+		var openTime int64
+		if err := rows.Scan(&openTime, &c.Open, &c.High, &c.Low, &c.Close); err != nil {
+			return nil, fmt.Errorf("failed to scan candle: %w", err)
+		}
+		c.OpenTime = time.UnixMilli(openTime).UTC()
+		candles = append(candles, c)
+	}
+	return candles, rows.Err()
+}
+
+// GetCandleIntervals lists the intervals stored for a coin
+func (r *SQLiteRepository) GetCandleIntervals(coinID string) ([]string, error) {
+	rows, err := r.conn.Query("SELECT DISTINCT interval FROM candles WHERE coin = ? ORDER BY interval", coinID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query candle intervals: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var intervals []string
+	for rows.Next() {
+		var interval string
+		if err := rows.Scan(&interval); err != nil {
+			return nil, fmt.Errorf("failed to scan interval: %w", err)
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals, rows.Err()
+}
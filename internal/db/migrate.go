@@ -0,0 +1,378 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationFile matches embedded migration names such as 0002_add_index.up.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change. Most are SQL files embedded from
+// migrations/ as NNNN_name.up.sql with an optional NNNN_name.down.sql; changes
+// that need logic SQL cannot express are written in Go and listed in goMigrations.
+type Migration struct {
+	Version int
+	Name    string
+	up      func(tx *sql.Tx) error
+	// down is nil for migrations that cannot be rolled back
+	down func(tx *sql.Tx) error
+}
+
+// String returns the migration as it is named on disk, e.g. 0001_initial
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// goMigrations are the migrations written in Go
+var goMigrations = []Migration{
+	marketDataMigration,
+	sourceMigration,
+	aggregateMigration,
+	timestampsMigration,
+	rollupsMigration,
+}
+
+// The columns added since the initial schema. Databases created before
+// migrations existed may have any of them already.
+var (
+	// marketDataMigration adds the market cap, 24h volume and provider update time
+	marketDataMigration = addColumnsMigration(3, "market_data",
+		column{table: "prices", name: "market_cap", def: "REAL NOT NULL DEFAULT 0"},
+		column{table: "prices", name: "volume_24h", def: "REAL NOT NULL DEFAULT 0"},
+		column{table: "prices", name: "last_updated", def: "DATETIME"},
+		column{table: "quotes", name: "market_cap", def: "REAL NOT NULL DEFAULT 0"},
+		column{table: "quotes", name: "volume_24h", def: "REAL NOT NULL DEFAULT 0"},
+	)
+	// sourceMigration records the provider each price and its quotes came from
+	sourceMigration = addColumnsMigration(4, "price_source",
+		column{table: "prices", name: "source", def: "TEXT NOT NULL DEFAULT ''"},
+		column{table: "quotes", name: "source", def: "TEXT NOT NULL DEFAULT ''"},
+	)
+	// aggregateMigration adds the spread and providers of aggregated prices
+	aggregateMigration = addColumnsMigration(5, "price_spread",
+		column{table: "prices", name: "spread", def: "REAL NOT NULL DEFAULT 0"},
+		column{table: "prices", name: "sources", def: "TEXT NOT NULL DEFAULT ''"},
+	)
+)
+
+// MigrationStatus describes a migration known to this build or recorded in the database
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	// Unknown marks a migration recorded in the database that this build does not have
+	Unknown bool
+}
+
+// loadMigrations returns the embedded and Go migrations ordered by version
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		match := migrationFile.FindStringSubmatch(e.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", e.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		data, err := migrationFiles.ReadFile("migrations/" + e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", e.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.up = execSQL(string(data))
+		} else {
+			m.down = execSQL(string(data))
+		}
+	}
+
+	for _, m := range goMigrations {
+		if _, ok := byVersion[m.Version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d", m.Version)
+		}
+		byVersion[m.Version] = &m
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == nil {
+			return nil, fmt.Errorf("migration %s has no up step", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+func execSQL(query string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// ensureMigrationsTable creates schema_migrations. A database created before
+// migrations existed has none; the migrations adopt its tables as they are.
+func (r *SQLiteRepository) ensureMigrationsTable() error {
+	_, err := r.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// LegacySchema reports whether the database has tables but no migration history,
+// as created before migrations existed. The first MigrateUp adopts it.
+func (r *SQLiteRepository) LegacySchema() (bool, error) {
+	tracked, err := r.tableExists("schema_migrations")
+	if err != nil || tracked {
+		return false, err
+	}
+	return r.tableExists("prices")
+}
+
+// MigrationStatus lists every known migration and whether it is applied, followed
+// by migrations recorded in the database that this build does not know. It only
+// reads the database; without a migration history every migration is pending.
+func (r *SQLiteRepository) MigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	tracked, err := r.tableExists("schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if !tracked {
+		status := make([]MigrationStatus, 0, len(migrations))
+		for _, m := range migrations {
+			status = append(status, MigrationStatus{Migration: m})
+		}
+		return status, nil
+	}
+
+	rows, err := r.conn.Query("SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	applied := make(map[int]MigrationStatus)
+	for rows.Next() {
+		var s MigrationStatus
+		var appliedAt any
+		if err := rows.Scan(&s.Version, &s.Name, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		s.Applied = true
+		// Databases not yet migrated by 0008_unix_ms_timestamps still hold text
+		ms, err := toUnixMs(appliedAt)
+		if err != nil {
+			return nil, fmt.Errorf("migration %d: %w", s.Version, err)
+		}
+		s.AppliedAt = fromUnixMs(ms.(int64))
+		s.Unknown = true
+		applied[s.Version] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Migration: m}
+		if a, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.AppliedAt
+			delete(applied, m.Version)
+		}
+		status = append(status, s)
+	}
+	for _, s := range applied {
+		status = append(status, s)
+	}
+	slices.SortFunc(status[len(migrations):], func(a, b MigrationStatus) int { return a.Version - b.Version })
+	return status, nil
+}
+
+// MigrateUp applies up to steps pending migrations in order, each in its own
+// transaction; steps <= 0 applies all of them. It refuses to run when the
+// database has migrations this build does not know.
+func (r *SQLiteRepository) MigrateUp(steps int) ([]Migration, error) {
+	if err := r.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+	status, err := r.MigrationStatus()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range status {
+		if s.Unknown {
+			return nil, fmt.Errorf("database has migration %s, which this build does not know; upgrade the bot", s.Migration)
+		}
+	}
+
+	var done []Migration
+	for _, s := range status {
+		if s.Applied {
+			continue
+		}
+		if steps > 0 && len(done) == steps {
+			break
+		}
+		err := r.inTx(func(tx *sql.Tx) error {
+			if err := s.up(tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+				s.Version, s.Name, time.Now().UnixMilli())
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("failed to apply migration %s: %w", s.Migration, err)
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// MigrateDown rolls back the steps most recently applied migrations, newest first
+func (r *SQLiteRepository) MigrateDown(steps int) ([]Migration, error) {
+	status, err := r.MigrationStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(status) - 1; i >= 0 && len(done) < steps; i-- {
+		s := status[i]
+		switch {
+		case !s.Applied:
+			continue
+		case s.Unknown:
+			return done, fmt.Errorf("cannot roll back migration %s, which this build does not know", s.Migration)
+		case s.down == nil:
+			return done, fmt.Errorf("migration %s cannot be rolled back", s.Migration)
+		}
+
+		err := r.inTx(func(tx *sql.Tx) error {
+			if err := s.down(tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", s.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("failed to roll back migration %s: %w", s.Migration, err)
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// inTx runs fn in a transaction, committing only if it succeeds
+func (r *SQLiteRepository) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := r.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteRepository) tableExists(table string) (bool, error) {
+	var n int
+	err := r.conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up table %s: %w", table, err)
+	}
+	return n > 0, nil
+}
+
+// column is a column added to a table by a migration
+type column struct {
+	table string
+	name  string
+	def   string
+}
+
+// addColumnsMigration returns a migration adding columns unless the table
+// already has them; rolling it back drops them
+func addColumnsMigration(version int, name string, columns ...column) Migration {
+	return Migration{
+		Version: version,
+		Name:    name,
+		up: func(tx *sql.Tx) error {
+			for _, col := range columns {
+				existing, err := tableColumns(tx, col.table)
+				if err != nil {
+					return err
+				}
+				if slices.Contains(existing, col.name) {
+					continue
+				}
+				if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", col.table, col.name, col.def)); err != nil {
+					return fmt.Errorf("failed to add %s.%s: %w", col.table, col.name, err)
+				}
+			}
+			return nil
+		},
+		down: func(tx *sql.Tx) error {
+			for _, col := range slices.Backward(columns) {
+				if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", col.table, col.name)); err != nil {
+					return fmt.Errorf("failed to drop %s.%s: %w", col.table, col.name, err)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func tableColumns(tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s') ORDER BY cid", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to inspect %s: %w", table, err)
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
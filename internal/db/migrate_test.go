@@ -0,0 +1,363 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// legacySchema is the schema databases were created with before migrations existed
+const legacySchema = `
+	CREATE TABLE prices (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		coin TEXT NOT NULL,
+		price REAL NOT NULL,
+		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX idx_prices_coin_timestamp ON prices(coin, timestamp);
+`
+
+// newLegacyDB creates a database file with the pre-migration schema holding the
+// given (price, timestamp text) rows of bitcoin, and returns its path
+func newLegacyDB(t *testing.T, rows ...legacyRow) string {
+	t.Helper()
+	return newLegacyDBWith(t, "", rows...)
+}
+
+// newLegacyDBWith is newLegacyDB with extra statements run on the schema before the rows are inserted
+func newLegacyDBWith(t *testing.T, extra string, rows ...legacyRow) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Exec(legacySchema + extra); err != nil {
+		t.Fatalf("create legacy schema: %v", err)
+	}
+	for _, row := range rows {
+		if _, err := conn.Exec("INSERT INTO prices (coin, price, timestamp) VALUES ('bitcoin', ?, ?)", row.price, row.timestamp); err != nil {
+			t.Fatalf("insert legacy row: %v", err)
+		}
+	}
+	return path
+}
+
+type legacyRow struct {
+	price     float64
+	timestamp string
+}
+
+// openRepo opens path without migrating it and closes it when the test ends
+func openRepo(t *testing.T, path string) *SQLiteRepository {
+	t.Helper()
+	repo, err := OpenSQLiteRepository(path)
+	if err != nil {
+		t.Fatalf("OpenSQLiteRepository: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+// newRepo creates a migrated database in a temporary directory
+func newRepo(t *testing.T) *SQLiteRepository {
+	t.Helper()
+	repo, err := NewSQLiteRepository(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+func countRows(t *testing.T, r *SQLiteRepository, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := r.conn.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+func TestMigrateUpDownRoundTrip(t *testing.T) {
+	repo := openRepo(t, filepath.Join(t.TempDir(), "test.db"))
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+
+	applied, err := repo.MigrateUp(0)
+	if err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(migrations))
+	}
+
+	at := time.Date(2026, 3, 9, 12, 30, 0, 123e6, time.UTC)
+	price := domain.CryptoPrice{Coin: "bitcoin", PriceUSD: 68000, Change24h: 1.5, HasChange24h: true, FetchedAt: at, Source: "coingecko"}
+	if err := repo.SavePrices(map[string]domain.CryptoPrice{"bitcoin": price}); err != nil {
+		t.Fatalf("SavePrices: %v", err)
+	}
+
+	// Roll back everything but 0001_initial, which would drop the data
+	rolledBack, err := repo.MigrateDown(len(migrations) - 1)
+	if err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+	if len(rolledBack) != len(migrations)-1 || rolledBack[0].Version != migrations[len(migrations)-1].Version {
+		t.Fatalf("rolled back %v, want every migration after 0001 newest first", rolledBack)
+	}
+	status, err := repo.MigrationStatus()
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	for _, s := range status {
+		if want := s.Version == 1; s.Applied != want {
+			t.Errorf("%s applied = %v, want %v", s.Migration, s.Applied, want)
+		}
+	}
+
+	if _, err := repo.MigrateUp(0); err != nil {
+		t.Fatalf("MigrateUp after MigrateDown: %v", err)
+	}
+	got, ok, err := repo.GetLatestPrice("bitcoin", at)
+	if err != nil || !ok {
+		t.Fatalf("GetLatestPrice = %v, %v", ok, err)
+	}
+	if got.PriceUSD != price.PriceUSD || !got.FetchedAt.Equal(at) {
+		t.Errorf("after round trip got %v at %s, want %v at %s", got.PriceUSD, got.FetchedAt, price.PriceUSD, at)
+	}
+	if n := countRows(t, repo, "SELECT COUNT(*) FROM price_rollups WHERE coin = 'bitcoin'"); n != len(domain.RollupResolutions) {
+		t.Errorf("rebuilt %d rollups, want %d", n, len(domain.RollupResolutions))
+	}
+}
+
+func TestMigrationStatusOnLegacyDB(t *testing.T) {
+	path := newLegacyDB(t, legacyRow{100, "2026-03-01 12:00:00"})
+	repo := openRepo(t, path)
+
+	legacy, err := repo.LegacySchema()
+	if err != nil || !legacy {
+		t.Fatalf("LegacySchema = %v, %v, want true", legacy, err)
+	}
+	status, err := repo.MigrationStatus()
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	for _, s := range status {
+		if s.Applied {
+			t.Errorf("%s reported applied on an untracked database", s.Migration)
+		}
+	}
+
+	// Status only reads: neither the history table nor the adopted columns exist yet
+	if tracked, _ := repo.tableExists("schema_migrations"); tracked {
+		t.Error("MigrationStatus created schema_migrations")
+	}
+	if n := countRows(t, repo, "SELECT COUNT(*) FROM pragma_table_info('prices')"); n != 4 {
+		t.Errorf("prices has %d columns after MigrationStatus, want the legacy 4", n)
+	}
+
+	if _, err := repo.MigrateUp(0); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	if legacy, _ := repo.LegacySchema(); legacy {
+		t.Error("LegacySchema still true after MigrateUp")
+	}
+	if _, ok, err := repo.GetLatestPrice("bitcoin", time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)); err != nil || !ok {
+		t.Errorf("GetLatestPrice after adoption = %v, %v", ok, err)
+	}
+}
+
+func TestMigrateIntermediateLegacySchemas(t *testing.T) {
+	// Versions before migrations added tables and columns as they went
+	const quotes = `
+		CREATE TABLE quotes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			coin TEXT NOT NULL,
+			currency TEXT NOT NULL,
+			price REAL NOT NULL,
+			change_24h REAL NOT NULL DEFAULT 0,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+	const marketData = `
+		ALTER TABLE prices ADD COLUMN market_cap REAL NOT NULL DEFAULT 0;
+		ALTER TABLE prices ADD COLUMN volume_24h REAL NOT NULL DEFAULT 0;
+		ALTER TABLE prices ADD COLUMN last_updated DATETIME;
+		ALTER TABLE quotes ADD COLUMN market_cap REAL NOT NULL DEFAULT 0;
+		ALTER TABLE quotes ADD COLUMN volume_24h REAL NOT NULL DEFAULT 0;
+	`
+	const source = `ALTER TABLE prices ADD COLUMN source TEXT NOT NULL DEFAULT '';`
+	const tracked = `
+		CREATE TABLE tracked_coins (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			symbol TEXT NOT NULL,
+			rank INTEGER NOT NULL,
+			first_seen DATETIME NOT NULL,
+			last_seen DATETIME NOT NULL,
+			active INTEGER NOT NULL DEFAULT 1
+		);
+	`
+
+	tests := []struct {
+		name  string
+		extra string
+	}{
+		{name: "quotes", extra: quotes},
+		{name: "market data", extra: quotes + marketData},
+		{name: "source", extra: quotes + marketData + source},
+		{name: "source without quotes", extra: source},
+		{name: "tracked coins", extra: quotes + marketData + source + tracked},
+	}
+
+	fresh := newRepo(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := NewSQLiteRepository(newLegacyDBWith(t, tt.extra, legacyRow{100, "2026-03-01 12:00:00"}))
+			if err != nil {
+				t.Fatalf("NewSQLiteRepository: %v", err)
+			}
+			t.Cleanup(func() { _ = repo.Close() })
+
+			for _, table := range []string{"prices", "quotes", "tracked_coins", "candles"} {
+				if got, want := schemaOf(t, repo, table), schemaOf(t, fresh, table); got != want {
+					t.Errorf("%s columns = %s, want %s as in a new database", table, got, want)
+				}
+			}
+			if n := countRows(t, repo, "SELECT COUNT(*) FROM prices"); n != 1 {
+				t.Errorf("kept %d prices, want 1", n)
+			}
+		})
+	}
+}
+
+// schemaOf lists the columns of table with their types, sorted by name
+func schemaOf(t *testing.T, r *SQLiteRepository, table string) string {
+	t.Helper()
+	var schema string
+	err := r.conn.QueryRow(`
+		SELECT COALESCE(group_concat(name || ' ' || type, ', '), '')
+		FROM (SELECT name, type FROM pragma_table_info(?) ORDER BY name)
+	`, table).Scan(&schema)
+	if err != nil {
+		t.Fatalf("inspect %s: %v", table, err)
+	}
+	return schema
+}
+
+func TestParseLegacyTimestamp(t *testing.T) {
+	want := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "go string", value: "2026-03-01 12:00:00 +0000 UTC", want: want},
+		{name: "go string with monotonic clock", value: "2026-03-01 12:00:00.5 +0000 UTC m=+0.000123001", want: want.Add(500 * time.Millisecond)},
+		{name: "go string in another zone", value: "2026-03-01 14:00:00 +0200 EET", want: want},
+		{name: "rfc 3339", value: "2026-03-01T12:00:00Z", want: want},
+		{name: "current_timestamp", value: "2026-03-01 12:00:00", want: want},
+		{name: "garbage", value: "yesterday", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLegacyTimestamp(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, errBadTimestamp) {
+					t.Fatalf("parseLegacyTimestamp(%q) error = %v, want errBadTimestamp", tt.value, err)
+				}
+				return
+			}
+			if err != nil || !got.Equal(tt.want) {
+				t.Errorf("parseLegacyTimestamp(%q) = %s, %v, want %s", tt.value, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateLegacyTimestamps(t *testing.T) {
+	path := newLegacyDB(t,
+		legacyRow{100, "2026-03-01 12:00:00.25 +0000 UTC m=+0.000123001"},
+		legacyRow{101, "2026-03-01T13:00:00Z"},
+		legacyRow{102, "2026-03-01 14:00:00"},
+		legacyRow{103, "not a time"},
+	)
+	repo, err := NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	history, err := repo.GetPriceHistory("bitcoin", day, day.Add(24*time.Hour), domain.ResolutionRaw)
+	if err != nil {
+		t.Fatalf("GetPriceHistory: %v", err)
+	}
+	want := []time.Time{
+		day.Add(12*time.Hour + 250*time.Millisecond),
+		day.Add(13 * time.Hour),
+		day.Add(14 * time.Hour),
+	}
+	if len(history) != len(want) {
+		t.Fatalf("kept %d samples, want %d", len(history), len(want))
+	}
+	for i, p := range history {
+		if !p.FetchedAt.Equal(want[i]) {
+			t.Errorf("sample %d at %s, want %s", i, p.FetchedAt, want[i])
+		}
+	}
+
+	if n, err := repo.QuarantineCount(); err != nil || n != 1 {
+		t.Errorf("QuarantineCount = %d, %v, want 1", n, err)
+	}
+	var table, reason string
+	if err := repo.conn.QueryRow("SELECT source_table, reason FROM quarantine").Scan(&table, &reason); err != nil {
+		t.Fatalf("read quarantine: %v", err)
+	}
+	if table != "prices" || reason == "" {
+		t.Errorf("quarantined %q row with reason %q, want a prices row with a reason", table, reason)
+	}
+}
+
+func TestMigrateDedupeKeepsNewestRow(t *testing.T) {
+	path := newLegacyDB(t,
+		legacyRow{100, "2026-03-01 12:00:00"},
+		legacyRow{101, "2026-03-01 12:00:00"},
+		legacyRow{200, "2026-03-01 13:00:00"},
+		// The same instant written in another form is a duplicate once converted
+		legacyRow{102, "2026-03-01T12:00:00Z"},
+	)
+	repo, err := NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	if n := countRows(t, repo, "SELECT COUNT(*) FROM prices"); n != 2 {
+		t.Errorf("kept %d rows, want 2", n)
+	}
+	noon := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	price, ok, err := repo.GetHistoricalPrice("bitcoin", noon)
+	if err != nil || !ok || price != 102 {
+		t.Errorf("price at noon = %v, %v, %v, want the newest row's 102", price, ok, err)
+	}
+}
@@ -0,0 +1,361 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// timestampsMigration rewrites every stored timestamp as integer Unix
+// milliseconds. Rows whose timestamps cannot be parsed are moved to the
+// quarantine table, with the reason, instead of being kept with a zero time.
+var timestampsMigration = Migration{
+	Version: 8,
+	Name:    "unix_ms_timestamps",
+	up: func(tx *sql.Tx) error {
+		if _, err := tx.Exec(createQuarantine); err != nil {
+			return fmt.Errorf("failed to create quarantine table: %w", err)
+		}
+		for _, t := range unixMsTables {
+			if err := rebuildTable(tx, t, toUnixMs); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// Rolling back restores the text timestamps; quarantined rows stay where they are
+	down: func(tx *sql.Tx) error {
+		for _, t := range legacyTables {
+			if err := rebuildTable(tx, t, toLegacyText); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+const createQuarantine = `
+	CREATE TABLE IF NOT EXISTS quarantine (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source_table TEXT NOT NULL,
+		row_data TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		quarantined_at INTEGER NOT NULL
+	) STRICT
+`
+
+// tableSchema is the definition a table is rebuilt with
+type tableSchema struct {
+	name string
+	// columns is the column list of CREATE TABLE
+	columns string
+	// strict tables reject values that do not match the column types
+	strict  bool
+	indexes []string
+	// timeColumns are converted; nullable ones may hold NULL
+	timeColumns map[string]bool
+	// mustConvert fails the migration on a row that cannot be converted instead of quarantining it
+	mustConvert bool
+}
+
+var unixMsTables = []tableSchema{
+	{
+		name: "prices",
+		columns: `
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			coin TEXT NOT NULL,
+			price REAL NOT NULL,
+			timestamp INTEGER NOT NULL,
+			market_cap REAL NOT NULL DEFAULT 0,
+			volume_24h REAL NOT NULL DEFAULT 0,
+			last_updated INTEGER,
+			source TEXT NOT NULL DEFAULT '',
+			spread REAL NOT NULL DEFAULT 0,
+			sources TEXT NOT NULL DEFAULT ''`,
+		strict:      true,
+		indexes:     []string{"CREATE INDEX idx_prices_coin_timestamp ON prices(coin, timestamp)"},
+		timeColumns: map[string]bool{"timestamp": false, "last_updated": true},
+	},
+	{
+		name: "quotes",
+		columns: `
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			coin TEXT NOT NULL,
+			currency TEXT NOT NULL,
+			price REAL NOT NULL,
+			change_24h REAL NOT NULL DEFAULT 0,
+			timestamp INTEGER NOT NULL,
+			market_cap REAL NOT NULL DEFAULT 0,
+			volume_24h REAL NOT NULL DEFAULT 0,
+			source TEXT NOT NULL DEFAULT ''`,
+		strict:      true,
+		indexes:     []string{"CREATE INDEX idx_quotes_coin_currency_timestamp ON quotes(coin, currency, timestamp)"},
+		timeColumns: map[string]bool{"timestamp": false},
+	},
+	{
+		name: "tracked_coins",
+		columns: `
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			symbol TEXT NOT NULL,
+			rank INTEGER NOT NULL,
+			first_seen INTEGER NOT NULL,
+			last_seen INTEGER NOT NULL,
+			active INTEGER NOT NULL DEFAULT 1`,
+		strict:      true,
+		timeColumns: map[string]bool{"first_seen": false, "last_seen": false},
+	},
+	{
+		name: "schema_migrations",
+		columns: `
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at INTEGER NOT NULL`,
+		strict:      true,
+		timeColumns: map[string]bool{"applied_at": false},
+		mustConvert: true,
+	},
+}
+
+// legacyTables are the definitions the tables had before this migration
+var legacyTables = []tableSchema{
+	{
+		name: "prices",
+		columns: `
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			coin TEXT NOT NULL,
+			price REAL NOT NULL,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			market_cap REAL NOT NULL DEFAULT 0,
+			volume_24h REAL NOT NULL DEFAULT 0,
+			last_updated DATETIME,
+			source TEXT NOT NULL DEFAULT '',
+			spread REAL NOT NULL DEFAULT 0,
+			sources TEXT NOT NULL DEFAULT ''`,
+		indexes:     []string{"CREATE INDEX idx_prices_coin_timestamp ON prices(coin, timestamp)"},
+		timeColumns: map[string]bool{"timestamp": false, "last_updated": true},
+	},
+	{
+		name: "quotes",
+		columns: `
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			coin TEXT NOT NULL,
+			currency TEXT NOT NULL,
+			price REAL NOT NULL,
+			change_24h REAL NOT NULL DEFAULT 0,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			market_cap REAL NOT NULL DEFAULT 0,
+			volume_24h REAL NOT NULL DEFAULT 0,
+			source TEXT NOT NULL DEFAULT ''`,
+		indexes:     []string{"CREATE INDEX idx_quotes_coin_currency_timestamp ON quotes(coin, currency, timestamp)"},
+		timeColumns: map[string]bool{"timestamp": false},
+	},
+	{
+		name: "tracked_coins",
+		columns: `
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			symbol TEXT NOT NULL,
+			rank INTEGER NOT NULL,
+			first_seen DATETIME NOT NULL,
+			last_seen DATETIME NOT NULL,
+			active INTEGER NOT NULL DEFAULT 1`,
+		timeColumns: map[string]bool{"first_seen": false, "last_seen": false},
+	},
+	{
+		name: "schema_migrations",
+		columns: `
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME NOT NULL`,
+		timeColumns: map[string]bool{"applied_at": false},
+		mustConvert: true,
+	},
+}
+
+// rebuildTable recreates a table with schema, copying every row and passing
+// its time columns through convert. Rows convert rejects are quarantined.
+func rebuildTable(tx *sql.Tx, schema tableSchema, convert func(v any) (any, error)) error {
+	columns, err := tableColumns(tx, schema.name)
+	if err != nil {
+		return err
+	}
+
+	rows, err := readRows(tx, schema.name, columns)
+	if err != nil {
+		return err
+	}
+
+	tmp := schema.name + "_rebuild"
+	create := fmt.Sprintf("CREATE TABLE %s (%s\n)", tmp, schema.columns)
+	if schema.strict {
+		create += " STRICT"
+	}
+	if _, err := tx.Exec(create); err != nil {
+		return fmt.Errorf("failed to create %s: %w", tmp, err)
+	}
+
+	insert, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		tmp, strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")))
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() { _ = insert.Close() }()
+
+	quarantined := 0
+	for _, row := range rows {
+		reason := ""
+		for i, col := range columns {
+			nullable, isTime := schema.timeColumns[col]
+			if !isTime {
+				continue
+			}
+			if row[i] == nil {
+				if !nullable {
+					reason = fmt.Sprintf("%s is NULL", col)
+				}
+				continue
+			}
+			converted, err := convert(row[i])
+			if err != nil {
+				reason = fmt.Sprintf("%s: %v", col, err)
+				break
+			}
+			row[i] = converted
+		}
+
+		if reason != "" && schema.mustConvert {
+			return fmt.Errorf("failed to convert %s row: %s", schema.name, reason)
+		}
+		if reason != "" {
+			if err := quarantineRow(tx, schema.name, columns, row, reason); err != nil {
+				return err
+			}
+			quarantined++
+			continue
+		}
+		if _, err := insert.Exec(row...); err != nil {
+			return fmt.Errorf("failed to copy %s row: %w", schema.name, err)
+		}
+	}
+
+	statements := append([]string{
+		"DROP TABLE " + schema.name,
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", tmp, schema.name),
+	}, schema.indexes...)
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to rebuild %s: %w", schema.name, err)
+		}
+	}
+
+	if quarantined > 0 {
+		log.Printf("Warning: moved %d %s rows with invalid timestamps to the quarantine table", quarantined, schema.name)
+	}
+	return nil
+}
+
+// readRows loads a whole table; the tables rebuilt by migrations are small
+func readRows(tx *sql.Tx, table string, columns []string) ([][]any, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var all [][]any
+	for rows.Next() {
+		row := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", table, err)
+		}
+		all = append(all, row)
+	}
+	return all, rows.Err()
+}
+
+// quarantineRow keeps a row that could not be migrated, as JSON, together with the reason
+func quarantineRow(tx *sql.Tx, table string, columns []string, row []any, reason string) error {
+	data := make(map[string]any, len(columns))
+	for i, col := range columns {
+		if b, ok := row[i].([]byte); ok {
+			data[col] = string(b)
+			continue
+		}
+		data[col] = row[i]
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode quarantined %s row: %w", table, err)
+	}
+
+	log.Printf("Warning: quarantining %s row %s: %s", table, encoded, reason)
+	_, err = tx.Exec("INSERT INTO quarantine (source_table, row_data, reason, quarantined_at) VALUES (?, ?, ?, ?)",
+		table, string(encoded), reason, time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to quarantine %s row: %w", table, err)
+	}
+	return nil
+}
+
+// toUnixMs converts a stored timestamp of any legacy form to Unix milliseconds
+func toUnixMs(v any) (any, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UnixMilli(), nil
+	case int64:
+		return v, nil
+	case string:
+		t, err := parseLegacyTimestamp(v)
+		if err != nil {
+			return nil, err
+		}
+		return t.UnixMilli(), nil
+	case []byte:
+		return toUnixMs(string(v))
+	default:
+		return nil, fmt.Errorf("unexpected timestamp value %v (%T)", v, v)
+	}
+}
+
+// toLegacyText converts Unix milliseconds back to the text form written before migration 8
+func toLegacyText(v any) (any, error) {
+	ms, ok := v.(int64)
+	if !ok {
+		return nil, fmt.Errorf("unexpected timestamp value %v (%T)", v, v)
+	}
+	return time.UnixMilli(ms).UTC().String(), nil
+}
+
+// legacyTimeLayouts are the text forms timestamps were stored in: Go's
+// time.Time String() as bound by the driver, RFC 3339 and SQLite's CURRENT_TIMESTAMP
+var legacyTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+}
+
+// errBadTimestamp is returned for text that matches none of legacyTimeLayouts
+var errBadTimestamp = errors.New("unparseable timestamp")
+
+// parseLegacyTimestamp parses a timestamp stored as text
+func parseLegacyTimestamp(s string) (time.Time, error) {
+	// Drop a monotonic clock reading such as " m=+0.000123001"
+	if i := strings.Index(s, " m="); i >= 0 {
+		s = s[:i]
+	}
+	for _, layout := range legacyTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w %q", errBadTimestamp, s)
+}
@@ -0,0 +1,9 @@
+-- Schema the bot had before it tracked migrations. IF NOT EXISTS lets databases
+-- created back then adopt it; every later table and column has its own migration.
+CREATE TABLE IF NOT EXISTS prices (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	coin TEXT NOT NULL,
+	price REAL NOT NULL,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_prices_coin_timestamp ON prices(coin, timestamp);
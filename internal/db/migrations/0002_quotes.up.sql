@@ -0,0 +1,10 @@
+-- Prices in currencies other than USD; 0003_market_data adds the market figures
+CREATE TABLE IF NOT EXISTS quotes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	coin TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	price REAL NOT NULL,
+	change_24h REAL NOT NULL DEFAULT 0,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_quotes_coin_currency_timestamp ON quotes(coin, currency, timestamp);
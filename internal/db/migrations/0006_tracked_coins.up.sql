@@ -0,0 +1,10 @@
+-- Coins tracked by market cap rank, remembered after they leave the top N
+CREATE TABLE IF NOT EXISTS tracked_coins (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	symbol TEXT NOT NULL,
+	rank INTEGER NOT NULL,
+	first_seen DATETIME NOT NULL,
+	last_seen DATETIME NOT NULL,
+	active INTEGER NOT NULL DEFAULT 1
+);
@@ -0,0 +1,11 @@
+-- OHLC candles as reported by the provider, keyed by their open time in Unix milliseconds
+CREATE TABLE IF NOT EXISTS candles (
+	coin TEXT NOT NULL,
+	interval TEXT NOT NULL,
+	open_time INTEGER NOT NULL,
+	open REAL NOT NULL,
+	high REAL NOT NULL,
+	low REAL NOT NULL,
+	close REAL NOT NULL,
+	PRIMARY KEY (coin, interval, open_time)
+);
@@ -0,0 +1,5 @@
+-- Removed duplicates are not restored
+DROP INDEX IF EXISTS idx_prices_coin_timestamp_source;
+DROP INDEX IF EXISTS idx_quotes_coin_currency_timestamp_source;
+CREATE INDEX idx_prices_coin_timestamp ON prices(coin, timestamp);
+CREATE INDEX idx_quotes_coin_currency_timestamp ON quotes(coin, currency, timestamp);
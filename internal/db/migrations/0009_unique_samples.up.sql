@@ -0,0 +1,12 @@
+-- Keep the last written row of every duplicated sample, as an upsert would have
+DELETE FROM prices
+WHERE id NOT IN (SELECT MAX(id) FROM prices GROUP BY coin, timestamp, source);
+
+DELETE FROM quotes
+WHERE id NOT IN (SELECT MAX(id) FROM quotes GROUP BY coin, currency, timestamp, source);
+
+-- The unique indexes cover the lookups the plain ones served
+DROP INDEX IF EXISTS idx_prices_coin_timestamp;
+DROP INDEX IF EXISTS idx_quotes_coin_currency_timestamp;
+CREATE UNIQUE INDEX idx_prices_coin_timestamp_source ON prices(coin, timestamp, source);
+CREATE UNIQUE INDEX idx_quotes_coin_currency_timestamp_source ON quotes(coin, currency, timestamp, source);
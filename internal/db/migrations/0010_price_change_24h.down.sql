@@ -0,0 +1 @@
+ALTER TABLE prices DROP COLUMN change_24h;
@@ -0,0 +1,2 @@
+-- NULL where the provider's 24h change is unknown, e.g. for backfilled samples
+ALTER TABLE prices ADD COLUMN change_24h REAL;
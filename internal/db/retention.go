@@ -0,0 +1,77 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// PruneResult counts the rows deleted by Prune
+type PruneResult struct {
+	Prices  int
+	Quotes  int
+	Rollups int
+}
+
+// Prune deletes the raw samples stored before rawBefore and the rollups before
+// rollupsBefore; a zero time keeps them all. Both cutoffs are moved back to the
+// start of their week, so every remaining rollup still covers whole buckets of
+// raw samples, or none, and RebuildRollups reproduces it.
+func (r *SQLiteRepository) Prune(rawBefore, rollupsBefore time.Time) (PruneResult, error) {
+	var result PruneResult
+	err := r.inTx(func(tx *sql.Tx) error {
+		if !rawBefore.IsZero() {
+			cutoff := domain.ResolutionWeek.Bucket(rawBefore).UnixMilli()
+			n, err := deleteRows(tx, "DELETE FROM prices WHERE timestamp < ?", cutoff)
+			if err != nil {
+				return fmt.Errorf("failed to prune prices: %w", err)
+			}
+			result.Prices = n
+			if n, err = deleteRows(tx, "DELETE FROM quotes WHERE timestamp < ?", cutoff); err != nil {
+				return fmt.Errorf("failed to prune quotes: %w", err)
+			}
+			result.Quotes = n
+		}
+		if !rollupsBefore.IsZero() {
+			cutoff := domain.ResolutionWeek.Bucket(rollupsBefore).UnixMilli()
+			n, err := deleteRows(tx, "DELETE FROM price_rollups WHERE bucket < ?", cutoff)
+			if err != nil {
+				return fmt.Errorf("failed to prune rollups: %w", err)
+			}
+			result.Rollups = n
+		}
+		return nil
+	})
+	return result, err
+}
+
+func deleteRows(tx *sql.Tx, query string, args ...any) (int, error) {
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// Vacuum rewrites the database file, returning the space of deleted rows to the file system
+func (r *SQLiteRepository) Vacuum() error {
+	if _, err := r.conn.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	return nil
+}
+
+// Size returns the size of the database file in bytes
+func (r *SQLiteRepository) Size() (int64, error) {
+	var pages, pageSize int64
+	if err := r.conn.QueryRow("PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := r.conn.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to read page size: %w", err)
+	}
+	return pages * pageSize, nil
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// rollupsMigration adds the hourly, daily and weekly aggregates of the prices
+// table and fills them from the samples already stored
+var rollupsMigration = Migration{
+	Version: 11,
+	Name:    "price_rollups",
+	up: func(tx *sql.Tx) error {
+		if _, err := tx.Exec(createRollups); err != nil {
+			return fmt.Errorf("failed to create price_rollups: %w", err)
+		}
+		_, err := rebuildRollups(tx)
+		return err
+	},
+	down: execSQL("DROP TABLE IF EXISTS price_rollups"),
+}
+
+const createRollups = `
+	CREATE TABLE price_rollups (
+		coin TEXT NOT NULL,
+		resolution TEXT NOT NULL,
+		bucket INTEGER NOT NULL,
+		open REAL NOT NULL,
+		high REAL NOT NULL,
+		low REAL NOT NULL,
+		close REAL NOT NULL,
+		mean REAL NOT NULL,
+		samples INTEGER NOT NULL,
+		closed_at INTEGER NOT NULL,
+		PRIMARY KEY (coin, resolution, bucket)
+	) STRICT
+`
+
+// weekOffset aligns weekly buckets on Monday; the Unix epoch was a Thursday
+const weekOffset = 4 * 24 * time.Hour
+
+// aggregateRollups inserts the rollups of the prices rows matching the %s
+// condition, whose parameters start at ?4. ?1 is the resolution, ?2 the bucket
+// width and ?3 the bucket offset, all times in Unix milliseconds. It must
+// compute the same buckets as domain.Resolution.Bucket.
+const aggregateRollups = `
+	INSERT INTO price_rollups (coin, resolution, bucket, open, high, low, close, mean, samples, closed_at)
+	SELECT coin, ?1, bucket,
+		MAX(CASE WHEN open_rank = 1 THEN price END),
+		MAX(price),
+		MIN(price),
+		MAX(CASE WHEN close_rank = 1 THEN price END),
+		AVG(price),
+		COUNT(*),
+		MAX(timestamp)
+	FROM (
+		SELECT coin, price, timestamp, bucket,
+			ROW_NUMBER() OVER (PARTITION BY coin, bucket ORDER BY timestamp, id) AS open_rank,
+			ROW_NUMBER() OVER (PARTITION BY coin, bucket ORDER BY timestamp DESC, id DESC) AS close_rank
+		FROM (
+			SELECT id, coin, price, timestamp, timestamp - (timestamp - ?3) %% ?2 AS bucket
+			FROM prices
+			WHERE %s
+		)
+	)
+	GROUP BY coin, bucket
+`
+
+// aggregate runs aggregateRollups for res and returns the number of rollups written
+func aggregate(tx *sql.Tx, res domain.Resolution, where string, args ...any) (int, error) {
+	offset := time.Duration(0)
+	if res == domain.ResolutionWeek {
+		offset = weekOffset
+	}
+	params := append([]any{string(res), res.Width().Milliseconds(), offset.Milliseconds()}, args...)
+	result, err := tx.Exec(fmt.Sprintf(aggregateRollups, where), params...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to aggregate %s rollups: %w", res, err)
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// rebuildRollups replaces the rollups with ones aggregated from the prices table.
+// Rollups before the week of a coin's oldest sample outlived their pruned samples
+// and are kept.
+func rebuildRollups(tx *sql.Tx) (map[domain.Resolution]int, error) {
+	stale := `
+		DELETE FROM price_rollups
+		WHERE bucket >= (
+			SELECT MIN(timestamp) - (MIN(timestamp) - ?1) % ?2
+			FROM prices
+			WHERE prices.coin = price_rollups.coin
+		)
+	`
+	week := domain.ResolutionWeek.Width().Milliseconds()
+	if _, err := tx.Exec(stale, weekOffset.Milliseconds(), week); err != nil {
+		return nil, fmt.Errorf("failed to clear rollups: %w", err)
+	}
+	counts := make(map[domain.Resolution]int, len(domain.RollupResolutions))
+	for _, res := range domain.RollupResolutions {
+		n, err := aggregate(tx, res, "1 = 1")
+		if err != nil {
+			return nil, err
+		}
+		counts[res] = n
+	}
+	return counts, nil
+}
+
+// sampleSpan is the time range of the samples just written for a coin
+type sampleSpan struct {
+	from, to time.Time
+}
+
+// add widens the span to include t
+func (s *sampleSpan) add(t time.Time) {
+	if s.from.IsZero() || t.Before(s.from) {
+		s.from = t
+	}
+	if t.After(s.to) {
+		s.to = t
+	}
+}
+
+// refreshRollups re-aggregates the buckets covering the samples just written,
+// so rollups stay exact whether a sample was inserted or overwritten
+func refreshRollups(tx *sql.Tx, spans map[string]*sampleSpan) error {
+	for coin, span := range spans {
+		for _, res := range domain.RollupResolutions {
+			from := res.Bucket(span.from).UnixMilli()
+			to := res.Bucket(span.to).Add(res.Width()).UnixMilli()
+			if _, err := tx.Exec("DELETE FROM price_rollups WHERE coin = ? AND resolution = ? AND bucket >= ? AND bucket < ?",
+				coin, string(res), from, to); err != nil {
+				return fmt.Errorf("failed to clear %s rollups of %s: %w", res, coin, err)
+			}
+			if _, err := aggregate(tx, res, "coin = ?4 AND timestamp >= ?5 AND timestamp < ?6", coin, from, to); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RebuildRollups regenerates the rollups from the stored samples and returns
+// how many were written per resolution
+func (r *SQLiteRepository) RebuildRollups() (map[domain.Resolution]int, error) {
+	var counts map[domain.Resolution]int
+	err := r.inTx(func(tx *sql.Tx) error {
+		var err error
+		counts, err = rebuildRollups(tx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild rollups: %w", err)
+	}
+	return counts, nil
+}
+
+// GetRollups returns the rollups of a coin whose closing sample lies between from and to, oldest first
+func (r *SQLiteRepository) GetRollups(coinID string, res domain.Resolution, from, to time.Time) ([]domain.Rollup, error) {
+	query := `
+		SELECT bucket, open, high, low, close, mean, samples, closed_at
+		FROM price_rollups
+		WHERE coin = ? AND resolution = ? AND closed_at BETWEEN ? AND ?
+		ORDER BY bucket ASC
+	`
+	rows, err := r.conn.Query(query, coinID, string(res), from.UnixMilli(), to.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rollups: %w", err)
+	}
+	defer rows.Close()
+
+	var rollups []domain.Rollup
+	for rows.Next() {
+		ru := domain.Rollup{Coin: coinID, Resolution: res}
+		var bucket, closedAt int64
+		if err := rows.Scan(&bucket, &ru.Open, &ru.High, &ru.Low, &ru.Close, &ru.Mean, &ru.Samples, &closedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan rollup: %w", err)
+		}
+		ru.Start = fromUnixMs(bucket)
+		ru.ClosedAt = fromUnixMs(closedAt)
+		rollups = append(rollups, ru)
+	}
+	return rollups, rows.Err()
+}
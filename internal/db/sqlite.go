@@ -3,6 +3,8 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/viczuno/go-crypto-bot/internal/domain"
@@ -14,8 +16,27 @@ type SQLiteRepository struct {
 	conn *sql.DB
 }
 
-// NewSQLiteRepository creates and initializes a new SQLite repository
+// NewSQLiteRepository opens the database and applies any pending migrations
 func NewSQLiteRepository(filepath string) (*SQLiteRepository, error) {
+	repo, err := OpenSQLiteRepository(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := repo.MigrateUp(0)
+	if err != nil {
+		_ = repo.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+	for _, m := range applied {
+		log.Printf("Applied database migration %s", m)
+	}
+
+	return repo, nil
+}
+
+// OpenSQLiteRepository opens the database without migrating it, for managing migrations
+func OpenSQLiteRepository(filepath string) (*SQLiteRepository, error) {
 	db, err := sql.Open("sqlite", filepath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -27,49 +48,74 @@ func NewSQLiteRepository(filepath string) (*SQLiteRepository, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	repo := &SQLiteRepository{conn: db}
-	if err := repo.initSchema(); err != nil {
-		_ = db.Close()
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
-	}
-
-	return repo, nil
-}
-
-// initSchema creates the required database tables
-func (r *SQLiteRepository) initSchema() error {
-	query := `
-		CREATE TABLE IF NOT EXISTS prices (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			coin TEXT NOT NULL,
-			price REAL NOT NULL,
-			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-		CREATE INDEX IF NOT EXISTS idx_prices_coin_timestamp ON prices(coin, timestamp);
-	`
-	_, err := r.conn.Exec(query)
-	return err
+	return &SQLiteRepository{conn: db}, nil
 }
 
-// SavePrices stores the current prices in the database
+// SavePrices stores the current prices in the database.
+// USD prices go to the prices table and other currencies to the quotes table.
+// A sample already stored for the same coin, timestamp and source is overwritten,
+// so retrying a save does not duplicate it. The rollups are updated in the same transaction.
 func (r *SQLiteRepository) SavePrices(prices map[string]domain.CryptoPrice) error {
 	tx, err := r.conn.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
-	stmt, err := tx.Prepare("INSERT INTO prices (coin, price, timestamp) VALUES (?, ?, ?)")
+	stmt, err := tx.Prepare(`
+		INSERT INTO prices (coin, price, change_24h, market_cap, volume_24h, last_updated, source, spread, sources, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (coin, timestamp, source) DO UPDATE SET
+			price = excluded.price,
+			change_24h = excluded.change_24h,
+			market_cap = excluded.market_cap,
+			volume_24h = excluded.volume_24h,
+			last_updated = excluded.last_updated,
+			spread = excluded.spread,
+			sources = excluded.sources
+	`)
 	if err != nil {
 		_ = tx.Rollback()
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer func() { _ = stmt.Close() }()
 
+	quoteStmt, err := tx.Prepare(`
+		INSERT INTO quotes (coin, currency, price, change_24h, market_cap, volume_24h, source, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (coin, currency, timestamp, source) DO UPDATE SET
+			price = excluded.price,
+			change_24h = excluded.change_24h,
+			market_cap = excluded.market_cap,
+			volume_24h = excluded.volume_24h
+	`)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() { _ = quoteStmt.Close() }()
+
+	spans := make(map[string]*sampleSpan)
 	for _, data := range prices {
-		if _, err := stmt.Exec(data.Coin, data.PriceUSD, data.FetchedAt); err != nil {
+		if _, err := stmt.Exec(data.Coin, data.PriceUSD, nullChange(data), data.MarketCap, data.Volume24h, nullTime(data.LastUpdated), data.Source, data.Spread, strings.Join(data.Sources, ","), data.FetchedAt.UnixMilli()); err != nil {
 			_ = tx.Rollback()
 			return fmt.Errorf("failed to insert price for %s: %w", data.Coin, err)
 		}
+
+		for currency, quote := range data.Quotes {
+			if currency == domain.BaseCurrency {
+				continue
+			}
+			if _, err := quoteStmt.Exec(data.Coin, currency, quote.Price, quote.Change24h, quote.MarketCap, quote.Volume24h, data.Source, data.FetchedAt.UnixMilli()); err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("failed to insert %s quote for %s: %w", currency, data.Coin, err)
+			}
+		}
+		addSpan(spans, data)
+	}
+
+	if err := refreshRollups(tx, spans); err != nil {
+		_ = tx.Rollback()
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -79,19 +125,79 @@ func (r *SQLiteRepository) SavePrices(prices map[string]domain.CryptoPrice) erro
 	return nil
 }
 
-// GetHistoricalPrice retrieves the price from a specified number of days ago
-func (r *SQLiteRepository) GetHistoricalPrice(coinID string, daysAgo int) (float64, bool, error) {
+// SavePriceHistory stores a series of samples, such as backfilled historical prices.
+// Samples whose coin, timestamp and source are already stored are skipped, so reruns are idempotent.
+func (r *SQLiteRepository) SavePriceHistory(prices []domain.CryptoPrice) (int, int, error) {
+	tx, err := r.conn.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	insert, err := tx.Prepare(`
+		INSERT INTO prices (coin, price, source, timestamp) VALUES (?, ?, ?, ?)
+		ON CONFLICT (coin, timestamp, source) DO NOTHING
+	`)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() { _ = insert.Close() }()
+
+	inserted, skipped := 0, 0
+	spans := make(map[string]*sampleSpan)
+	for _, data := range prices {
+		res, err := insert.Exec(data.Coin, data.PriceUSD, data.Source, data.FetchedAt.UnixMilli())
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, 0, fmt.Errorf("failed to insert price for %s: %w", data.Coin, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, 0, fmt.Errorf("failed to insert price for %s: %w", data.Coin, err)
+		}
+		if n == 0 {
+			skipped++
+			continue
+		}
+		inserted++
+		addSpan(spans, data)
+	}
+
+	if err := refreshRollups(tx, spans); err != nil {
+		_ = tx.Rollback()
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return inserted, skipped, nil
+}
+
+// GetHistoricalPrice retrieves the newest price stored at or before at. Once the
+// raw samples around at are pruned, it is the close of the newest rollup instead.
+func (r *SQLiteRepository) GetHistoricalPrice(coinID string, at time.Time) (float64, bool, error) {
 	query := `
 		SELECT price 
 		FROM prices 
-		WHERE coin = ? AND substr(timestamp, 1, 19) <= datetime('now', ?) 
-		ORDER BY timestamp DESC 
+		WHERE coin = ? AND timestamp <= ? 
+		ORDER BY timestamp DESC, id DESC 
 		LIMIT 1
 	`
-	timeModifier := fmt.Sprintf("-%d days", daysAgo)
-
 	var price float64
-	err := r.conn.QueryRow(query, coinID, timeModifier).Scan(&price)
+	err := r.conn.QueryRow(query, coinID, at.UnixMilli()).Scan(&price)
+	if err == sql.ErrNoRows {
+		rollupQuery := `
+			SELECT close 
+			FROM price_rollups 
+			WHERE coin = ? AND closed_at <= ? 
+			ORDER BY closed_at DESC 
+			LIMIT 1
+		`
+		err = r.conn.QueryRow(rollupQuery, coinID, at.UnixMilli()).Scan(&price)
+	}
 
 	if err == sql.ErrNoRows {
 		return 0, false, nil
@@ -103,17 +209,40 @@ func (r *SQLiteRepository) GetHistoricalPrice(coinID string, daysAgo int) (float
 	return price, true, nil
 }
 
-// GetPriceHistory retrieves price history for a coin for the last N days
-func (r *SQLiteRepository) GetPriceHistory(coinID string, days int) ([]domain.CryptoPrice, error) {
+// GetPriceHistory retrieves the price history of a coin between from and to.
+// Raw samples carry the provider-reported Change24h where one was stored.
+// Other resolutions are read from the rollups and carry only the close, except
+// for the newest point, which is the latest raw sample in full.
+func (r *SQLiteRepository) GetPriceHistory(coinID string, from, to time.Time, res domain.Resolution) ([]domain.CryptoPrice, error) {
+	if res != domain.ResolutionRaw {
+		rollups, err := r.GetRollups(coinID, res, from, to)
+		if err != nil {
+			return nil, err
+		}
+		prices := make([]domain.CryptoPrice, 0, len(rollups))
+		for _, ru := range rollups {
+			prices = append(prices, domain.CryptoPrice{Coin: coinID, PriceUSD: ru.Close, FetchedAt: ru.ClosedAt})
+		}
+		if n := len(prices); n > 0 {
+			latest, err := r.GetPriceHistory(coinID, prices[n-1].FetchedAt, prices[n-1].FetchedAt, domain.ResolutionRaw)
+			if err != nil {
+				return nil, err
+			}
+			// A pruned sample is only left in the rollups
+			if len(latest) > 0 {
+				prices[n-1] = latest[len(latest)-1]
+			}
+		}
+		return prices, nil
+	}
+
 	query := `
-		SELECT coin, price, timestamp 
+		SELECT coin, price, change_24h, source, last_updated, timestamp 
 		FROM prices 
-		WHERE coin = ? AND substr(timestamp, 1, 19) >= datetime('now', ?)
-		ORDER BY timestamp ASC
+		WHERE coin = ? AND timestamp BETWEEN ? AND ?
+		ORDER BY timestamp ASC, id ASC
 	`
-	timeModifier := fmt.Sprintf("-%d days", days)
-
-	rows, err := r.conn.Query(query, coinID, timeModifier)
+	rows, err := r.conn.Query(query, coinID, from.UnixMilli(), to.UnixMilli())
 	if err != nil {
 		return nil, fmt.Errorf("failed to query price history: %w", err)
 	}
@@ -122,41 +251,198 @@ func (r *SQLiteRepository) GetPriceHistory(coinID string, days int) ([]domain.Cr
 	var prices []domain.CryptoPrice
 	for rows.Next() {
 		var p domain.CryptoPrice
-		var timestamp string
-		if err := rows.Scan(&p.Coin, &p.PriceUSD, &timestamp); err != nil {
+		var timestamp int64
+		var change sql.NullFloat64
+		var lastUpdated sql.NullInt64
+		if err := rows.Scan(&p.Coin, &p.PriceUSD, &change, &p.Source, &lastUpdated, &timestamp); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
-		parsed, err := time.Parse(time.RFC3339, timestamp)
-		if err != nil {
-			parsed, err = time.Parse("2006-01-02 15:04:05 +0000 UTC", timestamp)
-			if err != nil {
-				parsed, _ = time.Parse("2006-01-02 15:04:05", timestamp[:min(19, len(timestamp))])
-			}
+		p.FetchedAt = fromUnixMs(timestamp)
+		p.Change24h, p.HasChange24h = change.Float64, change.Valid
+		if lastUpdated.Valid {
+			p.LastUpdated = fromUnixMs(lastUpdated.Int64)
 		}
-		p.FetchedAt = parsed
 		prices = append(prices, p)
 	}
 
 	return prices, rows.Err()
 }
 
-// GetHistoryDaysCount returns the number of days of history available for a coin
-func (r *SQLiteRepository) GetHistoryDaysCount(coinID string) (int, error) {
+// GetLatestPrice retrieves the most recent price stored for a coin at or before asOf;
+// of samples from several sources at the same time, the one written last wins.
+// Change24h is the provider-reported change, or when none was stored it is derived
+// from the last sample at least a day older than that one. Before the raw samples
+// kept by retention it falls back to the close of the newest rollup.
+func (r *SQLiteRepository) GetLatestPrice(coinID string, asOf time.Time) (domain.CryptoPrice, bool, error) {
 	query := `
-		SELECT CAST(julianday('now') - julianday(MIN(substr(timestamp, 1, 19))) AS INTEGER)
+		SELECT coin, price, change_24h, market_cap, volume_24h, last_updated, source, spread, sources, timestamp 
 		FROM prices 
-		WHERE coin = ?
+		WHERE coin = ? AND timestamp <= ? 
+		ORDER BY timestamp DESC, id DESC 
+		LIMIT 1
+	`
+	var p domain.CryptoPrice
+	var timestamp int64
+	var sources string
+	var change sql.NullFloat64
+	var lastUpdated sql.NullInt64
+	err := r.conn.QueryRow(query, coinID, asOf.UnixMilli()).Scan(&p.Coin, &p.PriceUSD, &change, &p.MarketCap, &p.Volume24h, &lastUpdated, &p.Source, &p.Spread, &sources, &timestamp)
+	if err == sql.ErrNoRows {
+		return r.getLatestRollup(coinID, asOf)
+	}
+	if err != nil {
+		return domain.CryptoPrice{}, false, fmt.Errorf("failed to query latest price: %w", err)
+	}
+	p.FetchedAt = fromUnixMs(timestamp)
+	if lastUpdated.Valid {
+		p.LastUpdated = fromUnixMs(lastUpdated.Int64)
+	}
+	if sources != "" {
+		p.Sources = strings.Split(sources, ",")
+	}
+
+	if change.Valid {
+		p.Change24h, p.HasChange24h = change.Float64, true
+	} else if err := r.deriveChange24h(&p); err != nil {
+		return domain.CryptoPrice{}, false, err
+	}
+
+	p.Quotes, err = r.getQuotes(coinID, p.FetchedAt, p.Source)
+	if err != nil {
+		return domain.CryptoPrice{}, false, err
+	}
+	p.Quotes[domain.BaseCurrency] = domain.Quote{
+		Price:     p.PriceUSD,
+		Change24h: p.Change24h,
+		MarketCap: p.MarketCap,
+		Volume24h: p.Volume24h,
+	}
+
+	return p, true, nil
+}
+
+// deriveChange24h sets the change of p against the last sample, or rollup close,
+// at least a day older
+func (r *SQLiteRepository) deriveChange24h(p *domain.CryptoPrice) error {
+	dayAgo, ok, err := r.GetHistoricalPrice(p.Coin, p.FetchedAt.AddDate(0, 0, -1))
+	if err != nil {
+		return fmt.Errorf("failed to query 24h price: %w", err)
+	}
+	if ok && dayAgo != 0 {
+		p.Change24h = (p.PriceUSD - dayAgo) / dayAgo * 100.0
+		p.HasChange24h = true
+	}
+	return nil
+}
+
+// getLatestRollup returns the close of the newest rollup closed at or before asOf,
+// for times whose raw samples were pruned. Only the USD price and its derived
+// 24h change are known.
+func (r *SQLiteRepository) getLatestRollup(coinID string, asOf time.Time) (domain.CryptoPrice, bool, error) {
+	query := `
+		SELECT close, closed_at 
+		FROM price_rollups 
+		WHERE coin = ? AND closed_at <= ? 
+		ORDER BY closed_at DESC 
+		LIMIT 1
+	`
+	p := domain.CryptoPrice{Coin: coinID}
+	var closedAt int64
+	err := r.conn.QueryRow(query, coinID, asOf.UnixMilli()).Scan(&p.PriceUSD, &closedAt)
+	if err == sql.ErrNoRows {
+		return domain.CryptoPrice{}, false, nil
+	}
+	if err != nil {
+		return domain.CryptoPrice{}, false, fmt.Errorf("failed to query latest rollup: %w", err)
+	}
+	p.FetchedAt = fromUnixMs(closedAt)
+	if err := r.deriveChange24h(&p); err != nil {
+		return domain.CryptoPrice{}, false, err
+	}
+	p.Quotes = map[string]domain.Quote{
+		domain.BaseCurrency: {Price: p.PriceUSD, Change24h: p.Change24h},
+	}
+	return p, true, nil
+}
+
+// getQuotes loads the non-USD quotes stored together with a prices row
+func (r *SQLiteRepository) getQuotes(coinID string, timestamp time.Time, source string) (map[string]domain.Quote, error) {
+	query := `
+		SELECT currency, price, change_24h, market_cap, volume_24h 
+		FROM quotes 
+		WHERE coin = ? AND timestamp = ? AND source = ?
 	`
-	var days sql.NullInt64
-	err := r.conn.QueryRow(query, coinID).Scan(&days)
+	rows, err := r.conn.Query(query, coinID, timestamp.UnixMilli(), source)
 	if err != nil {
-		return 0, fmt.Errorf("failed to query history days: %w", err)
+		return nil, fmt.Errorf("failed to query quotes: %w", err)
 	}
-	if !days.Valid {
-		return 0, nil
+	defer rows.Close()
+
+	quotes := make(map[string]domain.Quote)
+	for rows.Next() {
+		var currency string
+		var q domain.Quote
+		if err := rows.Scan(&currency, &q.Price, &q.Change24h, &q.MarketCap, &q.Volume24h); err != nil {
+			return nil, fmt.Errorf("failed to scan quote: %w", err)
+		}
+		quotes[currency] = q
+	}
+	return quotes, rows.Err()
+}
+
+// CoinSummary describes the stored history of a single coin
+type CoinSummary struct {
+	Coin    string
+	Samples int
+	First   time.Time
+	Last    time.Time
+}
+
+// GetCoinSummary returns the sample count and time span stored for a coin
+func (r *SQLiteRepository) GetCoinSummary(coinID string) (CoinSummary, error) {
+	query := `
+		SELECT COUNT(*), MIN(timestamp), MAX(timestamp)
+		FROM prices 
+		WHERE coin = ?
+	`
+	summary := CoinSummary{Coin: coinID}
+	var first, last sql.NullInt64
+	if err := r.conn.QueryRow(query, coinID).Scan(&summary.Samples, &first, &last); err != nil {
+		return summary, fmt.Errorf("failed to query coin summary: %w", err)
+	}
+	if first.Valid {
+		summary.First = fromUnixMs(first.Int64)
+	}
+	if last.Valid {
+		summary.Last = fromUnixMs(last.Int64)
+	}
+	return summary, nil
+}
+
+// Check runs SQLite's integrity check and reports any problem it finds
+func (r *SQLiteRepository) Check() error {
+	var result string
+	if err := r.conn.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("failed to run integrity check: %w", err)
 	}
-	return int(days.Int64), nil
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	return nil
+}
+
+// QuarantineCount returns the number of rows migrations set aside because they could not be converted
+func (r *SQLiteRepository) QuarantineCount() (int, error) {
+	exists, err := r.tableExists("quarantine")
+	if err != nil || !exists {
+		return 0, err
+	}
+	var n int
+	if err := r.conn.QueryRow("SELECT COUNT(*) FROM quarantine").Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count quarantined rows: %w", err)
+	}
+	return n, nil
 }
 
 // Close closes the database connection
@@ -167,5 +453,39 @@ func (r *SQLiteRepository) Close() error {
 	return nil
 }
 
-// Ensure SQLiteRepository implements PriceRepository
-var _ domain.PriceRepository = (*SQLiteRepository)(nil)
+// addSpan records the time of a sample written for its coin
+func addSpan(spans map[string]*sampleSpan, p domain.CryptoPrice) {
+	span, ok := spans[p.Coin]
+	if !ok {
+		span = &sampleSpan{}
+		spans[p.Coin] = span
+	}
+	span.add(p.FetchedAt)
+}
+
+// nullChange returns the 24h change to store, NULL when the provider reported none
+func nullChange(p domain.CryptoPrice) any {
+	if !p.HasChange24h {
+		return nil
+	}
+	return p.Change24h
+}
+
+// nullTime converts t to the stored Unix milliseconds, and zero times to NULL
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UnixMilli()
+}
+
+// fromUnixMs converts a stored timestamp to a UTC time
+func fromUnixMs(ms int64) time.Time {
+	return time.UnixMilli(ms).UTC()
+}
+
+// Ensure SQLiteRepository implements PriceRepository and HistoryWriter
+var (
+	_ domain.PriceRepository = (*SQLiteRepository)(nil)
+	_ domain.HistoryWriter   = (*SQLiteRepository)(nil)
+)
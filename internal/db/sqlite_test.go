@@ -0,0 +1,269 @@
+package db
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// monday is the start of a week bucket; 2026-03-09 is a Monday
+var monday = time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+
+func sample(usd float64, at time.Time) domain.CryptoPrice {
+	return domain.CryptoPrice{Coin: "bitcoin", PriceUSD: usd, FetchedAt: at, Source: "coingecko"}
+}
+
+func save(t *testing.T, repo *SQLiteRepository, prices ...domain.CryptoPrice) {
+	t.Helper()
+	for _, p := range prices {
+		if err := repo.SavePrices(map[string]domain.CryptoPrice{p.Coin: p}); err != nil {
+			t.Fatalf("SavePrices: %v", err)
+		}
+	}
+}
+
+func TestSavePricesUpsert(t *testing.T) {
+	repo := newRepo(t)
+	at := monday.Add(12 * time.Hour)
+
+	first := sample(100, at)
+	first.Quotes = map[string]domain.Quote{"eur": {Price: 90}}
+	second := sample(101, at)
+	second.Quotes = map[string]domain.Quote{"eur": {Price: 91}}
+	save(t, repo, first, second)
+
+	if n := countRows(t, repo, "SELECT COUNT(*) FROM prices"); n != 1 {
+		t.Errorf("stored %d prices for one sample, want 1", n)
+	}
+	if n := countRows(t, repo, "SELECT COUNT(*) FROM quotes"); n != 1 {
+		t.Errorf("stored %d quotes for one sample, want 1", n)
+	}
+	got, ok, err := repo.GetLatestPrice("bitcoin", at)
+	if err != nil || !ok {
+		t.Fatalf("GetLatestPrice = %v, %v", ok, err)
+	}
+	if got.PriceUSD != 101 || got.Quotes["eur"].Price != 91 {
+		t.Errorf("got %v USD and %v EUR, want the second save's 101 and 91", got.PriceUSD, got.Quotes["eur"].Price)
+	}
+	if ru := rollups(t, repo, domain.ResolutionHour); len(ru) != 1 || ru[0].Samples != 1 || ru[0].Close != 101 {
+		t.Errorf("hourly rollups = %+v, want one of the overwritten sample", ru)
+	}
+
+	// Another source at the same time is a separate sample; the history writer
+	// leaves stored samples alone
+	other := sample(102, at)
+	other.Source = "binance"
+	inserted, skipped, err := repo.SavePriceHistory([]domain.CryptoPrice{sample(99, at), other})
+	if err != nil {
+		t.Fatalf("SavePriceHistory: %v", err)
+	}
+	if inserted != 1 || skipped != 1 {
+		t.Errorf("SavePriceHistory inserted %d and skipped %d, want 1 and 1", inserted, skipped)
+	}
+	if n := countRows(t, repo, "SELECT COUNT(*) FROM prices WHERE price = 101"); n != 1 {
+		t.Error("SavePriceHistory overwrote the stored sample")
+	}
+
+	// Each source keeps its own quotes, and the sample written last is the latest
+	third := sample(103, at)
+	third.Source = "kraken"
+	third.Quotes = map[string]domain.Quote{"eur": {Price: 93}}
+	save(t, repo, third)
+	if n := countRows(t, repo, "SELECT COUNT(*) FROM quotes"); n != 2 {
+		t.Errorf("stored %d quotes for two sources, want 2", n)
+	}
+	got, _, err = repo.GetLatestPrice("bitcoin", at)
+	if err != nil {
+		t.Fatalf("GetLatestPrice: %v", err)
+	}
+	if got.Source != "kraken" || got.PriceUSD != 103 || got.Quotes["eur"].Price != 93 {
+		t.Errorf("got %v USD and %v EUR from %q, want kraken's 103 and 93", got.PriceUSD, got.Quotes["eur"].Price, got.Source)
+	}
+}
+
+// rollups returns every stored rollup of bitcoin at res
+func rollups(t *testing.T, repo *SQLiteRepository, res domain.Resolution) []domain.Rollup {
+	t.Helper()
+	ru, err := repo.GetRollups("bitcoin", res, time.Time{}, monday.AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatalf("GetRollups: %v", err)
+	}
+	return ru
+}
+
+func TestRollupBuckets(t *testing.T) {
+	repo := newRepo(t)
+	sunday := monday.Add(-time.Second)
+	samples := []domain.CryptoPrice{
+		sample(10, sunday.Add(-30*time.Minute)),
+		sample(30, sunday),
+		sample(20, monday),
+		sample(50, monday.Add(10*time.Minute)),
+		sample(40, monday.Add(59*time.Minute+59*time.Second)),
+		sample(60, monday.Add(time.Hour)),
+	}
+	if _, _, err := repo.SavePriceHistory(samples); err != nil {
+		t.Fatalf("SavePriceHistory: %v", err)
+	}
+
+	tests := []struct {
+		res    domain.Resolution
+		starts []time.Time
+		// first is the OHLC, mean and sample count of the bucket starting at monday
+		first domain.Rollup
+	}{
+		{
+			res:    domain.ResolutionHour,
+			starts: []time.Time{monday.Add(-time.Hour), monday, monday.Add(time.Hour)},
+			first:  domain.Rollup{Open: 20, High: 50, Low: 20, Close: 40, Mean: (20 + 50 + 40) / 3.0, Samples: 3},
+		},
+		{
+			res:    domain.ResolutionDay,
+			starts: []time.Time{monday.AddDate(0, 0, -1), monday},
+			first:  domain.Rollup{Open: 20, High: 60, Low: 20, Close: 60, Mean: (20 + 50 + 40 + 60) / 4.0, Samples: 4},
+		},
+		{
+			res:    domain.ResolutionWeek,
+			starts: []time.Time{monday.AddDate(0, 0, -7), monday},
+			first:  domain.Rollup{Open: 20, High: 60, Low: 20, Close: 60, Mean: (20 + 50 + 40 + 60) / 4.0, Samples: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.res), func(t *testing.T) {
+			got := rollups(t, repo, tt.res)
+			if len(got) != len(tt.starts) {
+				t.Fatalf("got %d buckets, want %d", len(got), len(tt.starts))
+			}
+			for i, ru := range got {
+				if !ru.Start.Equal(tt.starts[i]) {
+					t.Errorf("bucket %d starts at %s, want %s", i, ru.Start, tt.starts[i])
+				}
+				// SQL and Go must agree on bucket boundaries
+				if !ru.Start.Equal(tt.res.Bucket(ru.ClosedAt)) {
+					t.Errorf("bucket %d starts at %s, but Bucket puts its close at %s", i, ru.Start, tt.res.Bucket(ru.ClosedAt))
+				}
+			}
+			ru := got[slices.IndexFunc(tt.starts, monday.Equal)]
+			if ru.Open != tt.first.Open || ru.High != tt.first.High || ru.Low != tt.first.Low ||
+				ru.Close != tt.first.Close || ru.Mean != tt.first.Mean || ru.Samples != tt.first.Samples {
+				t.Errorf("bucket at %s = %+v, want %+v", monday, ru, tt.first)
+			}
+		})
+	}
+
+	// Weeks start on Monday, not on the Thursday the Unix epoch fell on
+	if got := domain.ResolutionWeek.Bucket(monday.AddDate(0, 0, 3)); !got.Equal(monday) {
+		t.Errorf("week of Thursday starts at %s, want %s", got, monday)
+	}
+
+	// The incremental rollups equal a rebuild from scratch
+	before := rollups(t, repo, domain.ResolutionHour)
+	if _, err := repo.RebuildRollups(); err != nil {
+		t.Fatalf("RebuildRollups: %v", err)
+	}
+	after := rollups(t, repo, domain.ResolutionHour)
+	if len(before) != len(after) {
+		t.Fatalf("rebuild changed the hourly rollups from %d to %d", len(before), len(after))
+	}
+	for i := range before {
+		if before[i] != after[i] {
+			t.Errorf("rebuilt rollup %d = %+v, want %+v", i, after[i], before[i])
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	lastWeek := monday.AddDate(0, 0, -7)
+	samples := []domain.CryptoPrice{
+		sample(100, lastWeek.Add(12*time.Hour)),
+		sample(101, monday.Add(-time.Minute)),
+		sample(102, monday),
+		sample(103, monday.AddDate(0, 0, 3)),
+	}
+
+	tests := []struct {
+		name                     string
+		rawBefore, rollupsBefore time.Time
+		want                     PruneResult
+		// raw is the number of samples left
+		raw int
+	}{
+		{name: "zero keeps everything", raw: 4},
+		{
+			// A cutoff mid-week moves back to its Monday, keeping that week whole
+			name:      "raw",
+			rawBefore: monday.AddDate(0, 0, 2),
+			want:      PruneResult{Prices: 2, Quotes: 2},
+			raw:       2,
+		},
+		{
+			name:          "raw and rollups",
+			rawBefore:     monday,
+			rollupsBefore: monday.Add(time.Hour),
+			// The hour, day and week buckets of last week's two samples
+			want: PruneResult{Prices: 2, Quotes: 2, Rollups: 2 + 2 + 1},
+			raw:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo(t)
+			for _, s := range samples {
+				s.Quotes = map[string]domain.Quote{"eur": {Price: s.PriceUSD}}
+				save(t, repo, s)
+			}
+
+			got, err := repo.Prune(tt.rawBefore, tt.rollupsBefore)
+			if err != nil {
+				t.Fatalf("Prune: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Prune = %+v, want %+v", got, tt.want)
+			}
+			if n := countRows(t, repo, "SELECT COUNT(*) FROM prices"); n != tt.raw {
+				t.Errorf("%d samples left, want %d", n, tt.raw)
+			}
+			// Rollups outlive the raw samples unless pruned themselves
+			if n := countRows(t, repo, "SELECT COUNT(*) FROM price_rollups WHERE bucket < ?", monday.UnixMilli()); tt.rollupsBefore.IsZero() && n == 0 {
+				t.Error("rollups of last week were pruned with its samples")
+			}
+		})
+	}
+}
+
+func TestPrunedHistoryFallsBackToRollups(t *testing.T) {
+	repo := newRepo(t)
+	lastWeek := monday.AddDate(0, 0, -7)
+	save(t, repo,
+		sample(100, lastWeek.Add(12*time.Hour)),
+		sample(110, lastWeek.Add(36*time.Hour)),
+		sample(120, monday.Add(time.Hour)),
+	)
+	if _, err := repo.Prune(monday, time.Time{}); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+
+	asOf := lastWeek.AddDate(0, 0, 3)
+	got, ok, err := repo.GetLatestPrice("bitcoin", asOf)
+	if err != nil || !ok {
+		t.Fatalf("GetLatestPrice = %v, %v, want the rollup close", ok, err)
+	}
+	if got.PriceUSD != 110 || !got.FetchedAt.Equal(lastWeek.Add(36*time.Hour)) {
+		t.Errorf("GetLatestPrice = %v at %s, want 110 at %s", got.PriceUSD, got.FetchedAt, lastWeek.Add(36*time.Hour))
+	}
+	if want := 10.0; got.Change24h != want {
+		t.Errorf("Change24h = %v, want %v derived from the day-old rollup", got.Change24h, want)
+	}
+
+	price, ok, err := repo.GetHistoricalPrice("bitcoin", asOf)
+	if err != nil || !ok || price != 110 {
+		t.Errorf("GetHistoricalPrice = %v, %v, %v, want 110", price, ok, err)
+	}
+	if _, ok, _ := repo.GetLatestPrice("bitcoin", lastWeek); ok {
+		t.Error("GetLatestPrice found a price before the first sample")
+	}
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// TrackedCoin is a coin of the automatically tracked top-N universe.
+// Coins that drop out stay in the table, inactive, so their history remains known.
+type TrackedCoin struct {
+	domain.CoinMetadata
+	Rank      int
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Active    bool
+}
+
+// SyncTrackedCoins makes coins, ranked in slice order, the active universe.
+// It returns the ids that joined and the ids that dropped out.
+func (r *SQLiteRepository) SyncTrackedCoins(coins []domain.CoinMetadata, at time.Time) ([]string, []string, error) {
+	previous, err := r.GetTrackedCoins(false)
+	if err != nil {
+		return nil, nil, err
+	}
+	wasActive := make(map[string]bool, len(previous))
+	for _, c := range previous {
+		wasActive[c.ID] = true
+	}
+
+	tx, err := r.conn.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE tracked_coins SET active = 0 WHERE active = 1"); err != nil {
+		_ = tx.Rollback()
+		return nil, nil, fmt.Errorf("failed to reset tracked coins: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO tracked_coins (id, name, symbol, rank, first_seen, last_seen, active)
+		VALUES (?, ?, ?, ?, ?, ?, 1)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			symbol = excluded.symbol,
+			rank = excluded.rank,
+			last_seen = excluded.last_seen,
+			active = 1
+	`)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	var added []string
+	current := make(map[string]bool, len(coins))
+	for i, c := range coins {
+		if _, err := stmt.Exec(c.ID, c.Name, c.Symbol, i+1, at.UnixMilli(), at.UnixMilli()); err != nil {
+			_ = tx.Rollback()
+			return nil, nil, fmt.Errorf("failed to track %s: %w", c.ID, err)
+		}
+		current[c.ID] = true
+		if !wasActive[c.ID] {
+			added = append(added, c.ID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	var dropped []string
+	for _, c := range previous {
+		if !current[c.ID] {
+			dropped = append(dropped, c.ID)
+		}
+	}
+	return added, dropped, nil
+}
+
+// GetTrackedCoins lists the tracked universe by rank; with all set, coins that
+// dropped out are included after the active ones
+func (r *SQLiteRepository) GetTrackedCoins(all bool) ([]TrackedCoin, error) {
+	query := `
+		SELECT id, name, symbol, rank, first_seen, last_seen, active
+		FROM tracked_coins
+		WHERE active = 1 OR ?
+		ORDER BY active DESC, rank ASC
+	`
+	rows, err := r.conn.Query(query, all)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tracked coins: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var coins []TrackedCoin
+	for rows.Next() {
+		var c TrackedCoin
+		var firstSeen, lastSeen int64
+		if err := rows.Scan(&c.ID, &c.Name, &c.Symbol, &c.Rank, &firstSeen, &lastSeen, &c.Active); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		c.FirstSeen = fromUnixMs(firstSeen)
+		c.LastSeen = fromUnixMs(lastSeen)
+		coins = append(coins, c)
+	}
+	return coins, rows.Err()
+}
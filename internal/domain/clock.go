@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// Clock tells the current time. Reports take it from a Clock so they can be
+// reproduced, or rebuilt as they looked at a past moment.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface
+type ClockFunc func() time.Time
+
+// Now calls f
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the wall clock
+var SystemClock Clock = ClockFunc(time.Now)
+
+// FixedClock returns a clock stopped at t
+func FixedClock(t time.Time) Clock {
+	return ClockFunc(func() time.Time { return t })
+}
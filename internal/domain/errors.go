@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoData means a provider answered but had no price for the coin
+var ErrNoData = errors.New("no data returned")
+
+// MissingPrice explains why a coin is absent from a fetch result
+type MissingPrice struct {
+	Coin string
+	Err  error
+}
+
+// PartialError is returned by PriceFetcher.FetchPrices together with the prices
+// it did get, listing the coins it could not price
+type PartialError struct {
+	Missing []MissingPrice
+}
+
+func (e *PartialError) Error() string {
+	parts := make([]string, len(e.Missing))
+	for i, m := range e.Missing {
+		parts[i] = fmt.Sprintf("%s (%v)", m.Coin, m.Err)
+	}
+	return fmt.Sprintf("no price for %d coins: %s", len(e.Missing), strings.Join(parts, ", "))
+}
+
+// Unwrap exposes the reasons so errors.Is can classify them
+func (e *PartialError) Unwrap() []error {
+	errs := make([]error, len(e.Missing))
+	for i, m := range e.Missing {
+		errs[i] = m.Err
+	}
+	return errs
+}
+
+// Reasons maps each missing coin id to its reason
+func (e *PartialError) Reasons() map[string]error {
+	reasons := make(map[string]error, len(e.Missing))
+	for _, m := range e.Missing {
+		reasons[m.Coin] = m.Err
+	}
+	return reasons
+}
@@ -1,20 +1,45 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
-// PriceFetcher defines the interface for fetching cryptocurrency prices
+// PriceFetcher defines the interface for fetching cryptocurrency prices.
+// When only some coins can be priced it returns them with a *PartialError.
 type PriceFetcher interface {
 	FetchPrices(ctx context.Context, coinIDs []string) (map[string]CryptoPrice, error)
 }
 
-// PriceRepository defines the interface for storing and retrieving price data
+// HistoryFetcher defines the interface for fetching historical prices of a single coin
+type HistoryFetcher interface {
+	FetchHistoricalRange(ctx context.Context, coinID string, from, to time.Time) ([]CryptoPrice, error)
+}
+
+// CandleFetcher defines the interface for fetching OHLC candles of a single coin
+type CandleFetcher interface {
+	FetchOHLC(ctx context.Context, coinID string, days int) ([]Candle, error)
+}
+
+// PriceRepository defines the interface for storing and retrieving price data.
+// Queries take explicit times so that stored history can be read as of any moment.
 type PriceRepository interface {
 	SavePrices(prices map[string]CryptoPrice) error
-	GetHistoricalPrice(coinID string, daysAgo int) (float64, bool, error)
-	GetPriceHistory(coinID string, days int) ([]CryptoPrice, error)
+	// GetLatestPrice returns the newest price stored at or before asOf
+	GetLatestPrice(coinID string, asOf time.Time) (CryptoPrice, bool, error)
+	// GetHistoricalPrice returns the newest price stored at or before at
+	GetHistoricalPrice(coinID string, at time.Time) (float64, bool, error)
+	// GetPriceHistory returns the prices stored between from and to, oldest first.
+	// Rollup resolutions return one price per bucket: its close, timed at the closing sample.
+	GetPriceHistory(coinID string, from, to time.Time, res Resolution) ([]CryptoPrice, error)
 	Close() error
 }
 
+// HistoryWriter defines the interface for storing historical samples idempotently
+type HistoryWriter interface {
+	SavePriceHistory(prices []CryptoPrice) (inserted, skipped int, err error)
+}
+
 // ReadmeGenerator defines the interface for generating README content
 type ReadmeGenerator interface {
 	Generate(stats []CoinStats, coins []CoinMetadata) string
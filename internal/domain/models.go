@@ -2,12 +2,54 @@ package domain
 
 import "time"
 
+// BaseCurrency is the quote currency stored in PriceUSD and always fetched
+const BaseCurrency = "usd"
+
 // CryptoPrice represents the current price data for a cryptocurrency
 type CryptoPrice struct {
 	Coin      string
 	PriceUSD  float64
 	Change24h float64
-	FetchedAt time.Time
+	// HasChange24h is set when Change24h is known: reported by the provider, or
+	// derived from stored history when read back. Without it the change is unknown.
+	HasChange24h bool
+	MarketCap    float64
+	Volume24h    float64
+	FetchedAt    time.Time
+	// LastUpdated is the provider's own timestamp for the quote; zero if unknown
+	LastUpdated time.Time
+	// Source names the provider that supplied the price, e.g. "coingecko"
+	Source string
+	// Sources lists the providers whose quotes were aggregated into the price, if any
+	Sources []string
+	// Spread is the gap between the highest and lowest accepted provider quote, as a percentage of the price
+	Spread float64
+	// Quotes holds the price in every fetched currency, keyed by lower-case code (e.g. "eur")
+	Quotes map[string]Quote
+	// Status is StatusOK for fresh prices; StatusReason explains any other status
+	Status       PriceStatus
+	StatusReason string
+}
+
+// PriceStatus tells whether a coin's price is current
+type PriceStatus string
+
+// Price statuses
+const (
+	// StatusOK is a price fetched in this run or loaded for an offline report
+	StatusOK PriceStatus = "ok"
+	// StatusStale is the last stored price, reused because the fetch failed for the coin
+	StatusStale PriceStatus = "stale"
+	// StatusMissing marks a coin left without any price
+	StatusMissing PriceStatus = "missing"
+)
+
+// Quote is a price denominated in a single currency
+type Quote struct {
+	Price     float64
+	Change24h float64
+	MarketCap float64
+	Volume24h float64
 }
 
 // PriceChange represents historical price change data
@@ -26,18 +68,29 @@ type CoinStats struct {
 	Symbol    string
 	Price     float64
 	Change24h float64
-	Change7d  PriceChange
-	Change30d PriceChange
+	// HasChange24h is false when the 24h change is unknown, so reports can say so
+	HasChange24h bool
+	MarketCap    float64
+	Volume24h    float64
+	LastUpdated  time.Time
+	Source       string
+	Sources      []string
+	Spread       float64
+	Change7d     PriceChange
+	Change30d    PriceChange
+	Quotes       map[string]Quote
+	Status       PriceStatus
+	StatusReason string
 }
 
 // CoinMetadata contains display information for coins
 type CoinMetadata struct {
-	ID     string
-	Name   string
-	Symbol string
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
 }
 
-// DefaultCoins returns the list of tracked cryptocurrencies with metadata
+// DefaultCoins returns the fallback list of tracked cryptocurrencies used when no config provides one
 func DefaultCoins() []CoinMetadata {
 	return []CoinMetadata{
 		{ID: "bitcoin", Name: "Bitcoin", Symbol: "BTC"},
@@ -47,3 +100,15 @@ func DefaultCoins() []CoinMetadata {
 		{ID: "polkadot", Name: "Polkadot", Symbol: "DOT"},
 	}
 }
+
+// Candle is an OHLC price candle in USD
+type Candle struct {
+	Coin string
+	// Interval is the candle width as a label such as "30m", "4h" or "4d"
+	Interval string
+	OpenTime time.Time
+	Open     float64
+	High     float64
+	Low      float64
+	Close    float64
+}
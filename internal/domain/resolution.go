@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Resolution is the granularity price history is read at
+type Resolution string
+
+// Resolutions; all but ResolutionRaw are kept as rollups of the stored samples
+const (
+	// ResolutionRaw is every stored sample
+	ResolutionRaw  Resolution = "raw"
+	ResolutionHour Resolution = "1h"
+	ResolutionDay  Resolution = "1d"
+	// ResolutionWeek buckets start on Monday, 00:00 UTC
+	ResolutionWeek Resolution = "1w"
+)
+
+// RollupResolutions lists the resolutions kept as rollups, finest first
+var RollupResolutions = []Resolution{ResolutionHour, ResolutionDay, ResolutionWeek}
+
+// ParseResolution parses a resolution name such as "1d"
+func ParseResolution(s string) (Resolution, error) {
+	switch r := Resolution(s); r {
+	case ResolutionRaw, ResolutionHour, ResolutionDay, ResolutionWeek:
+		return r, nil
+	}
+	return "", fmt.Errorf("unknown resolution %q, want raw, 1h, 1d or 1w", s)
+}
+
+// ResolutionFor picks the coarsest resolution that still charts a span in detail
+func ResolutionFor(span time.Duration) Resolution {
+	const day = 24 * time.Hour
+	switch {
+	case span <= 2*day:
+		return ResolutionRaw
+	case span <= 90*day:
+		return ResolutionHour
+	case span <= 3*365*day:
+		return ResolutionDay
+	default:
+		return ResolutionWeek
+	}
+}
+
+// Width returns the length of a bucket, or zero for ResolutionRaw
+func (r Resolution) Width() time.Duration {
+	switch r {
+	case ResolutionHour:
+		return time.Hour
+	case ResolutionDay:
+		return 24 * time.Hour
+	case ResolutionWeek:
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+// Bucket returns the start of the bucket t falls in. Truncate counts from
+// January 1 of year 1, a Monday, so weeks start on Monday.
+func (r Resolution) Bucket(t time.Time) time.Time {
+	if r.Width() == 0 {
+		return t
+	}
+	return t.UTC().Truncate(r.Width())
+}
+
+// Rollup aggregates the samples of a coin that fall in one bucket
+type Rollup struct {
+	Coin       string
+	Resolution Resolution
+	// Start is the start of the bucket
+	Start time.Time
+	Open  float64
+	High  float64
+	Low   float64
+	Close float64
+	Mean  float64
+	// Samples is the number of stored prices aggregated
+	Samples int
+	// ClosedAt is the time of the last sample, whose price is Close
+	ClosedAt time.Time
+}
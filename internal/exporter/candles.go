@@ -0,0 +1,111 @@
+package exporter
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// CoinCandles represents the JSON structure for a coin's OHLC candles
+type CoinCandles struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Symbol    string `json:"symbol"`
+	UpdatedAt string `json:"updated_at"`
+	// Candles holds the candles of each stored interval, such as "4h", oldest first
+	Candles map[string][]CandleDataPoint `json:"candles"`
+}
+
+// CandleDataPoint represents a single OHLC candle
+type CandleDataPoint struct {
+	OpenTime string  `json:"open_time"`
+	Open     float64 `json:"open"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	Close    float64 `json:"close"`
+}
+
+// CandleProvider retrieves stored candles for coins
+type CandleProvider interface {
+	GetCandleIntervals(coinID string) ([]string, error)
+	GetCandles(coinID, interval string, from, to time.Time) ([]domain.Candle, error)
+}
+
+// WithCandlesPath sets the directory per-coin candle files are written to;
+// without it ExportCandles does nothing
+func WithCandlesPath(dir string) Option {
+	return func(e *HugoExporter) {
+		e.candlesPath = dir
+	}
+}
+
+// ExportCandles writes a candle file for every coin with candles opened in the last days days
+func (e *HugoExporter) ExportCandles(coins []domain.CoinMetadata, candleProvider CandleProvider, days int) error {
+	if e.candlesPath == "" {
+		return nil
+	}
+
+	now := e.clock.Now()
+	from := now.AddDate(0, 0, -days)
+	for _, coin := range coins {
+		intervals, err := candleProvider.GetCandleIntervals(coin.ID)
+		if err != nil {
+			log.Printf("Warning: failed to get candles for %s: %v", coin.ID, err)
+			continue
+		}
+
+		var candles []domain.Candle
+		for _, interval := range intervals {
+			c, err := candleProvider.GetCandles(coin.ID, interval, from, now)
+			if err != nil {
+				log.Printf("Warning: failed to get %s candles for %s: %v", interval, coin.ID, err)
+				continue
+			}
+			candles = append(candles, c...)
+		}
+		if len(candles) == 0 {
+			continue
+		}
+
+		if err := e.ExportCoinCandles(coin, candles); err != nil {
+			log.Printf("Warning: failed to export candles for %s: %v", coin.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// ExportCoinCandles exports the candle file of a coin, grouping candles by interval
+func (e *HugoExporter) ExportCoinCandles(coin domain.CoinMetadata, candles []domain.Candle) error {
+	if err := os.MkdirAll(e.candlesPath, dirMode); err != nil {
+		return err
+	}
+
+	data := CoinCandles{
+		ID:        coin.ID,
+		Name:      coin.Name,
+		Symbol:    coin.Symbol,
+		UpdatedAt: e.clock.Now().UTC().Format(time.RFC3339),
+		Candles:   make(map[string][]CandleDataPoint),
+	}
+	for _, c := range candles {
+		data.Candles[c.Interval] = append(data.Candles[c.Interval], CandleDataPoint{
+			OpenTime: c.OpenTime.UTC().Format(time.RFC3339),
+			Open:     c.Open,
+			High:     c.High,
+			Low:      c.Low,
+			Close:    c.Close,
+		})
+	}
+
+	filePath := filepath.Join(e.candlesPath, coin.ID+".json")
+	if err := writeJSON(filePath, data); err != nil {
+		return err
+	}
+
+	log.Printf("Exported %s candles (%d)", coin.Name, len(candles))
+	return nil
+}
@@ -19,6 +19,16 @@ const (
 type CryptoData struct {
 	UpdatedAt string           `json:"updated_at"`
 	Coins     []CryptoDataItem `json:"coins"`
+	// Missing lists coins without any price in this run
+	Missing []MissingItem `json:"missing,omitempty"`
+}
+
+// MissingItem is a coin that could not be priced, with the reason
+type MissingItem struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
+	Reason string `json:"reason"`
 }
 
 // CryptoDataItem represents a single coin entry in the JSON
@@ -28,20 +38,45 @@ type CryptoDataItem struct {
 	Symbol      string  `json:"symbol"`
 	Price       float64 `json:"price"`
 	Change24h   float64 `json:"change_24h"`
+	Change24hOk bool    `json:"change_24h_ok"`
 	Change7d    float64 `json:"change_7d"`
 	Change7dOk  bool    `json:"change_7d_ok"`
 	Change30d   float64 `json:"change_30d"`
 	Change30dOk bool    `json:"change_30d_ok"`
+	MarketCap   float64 `json:"market_cap,omitempty"`
+	Volume24h   float64 `json:"volume_24h,omitempty"`
+	// LastUpdated is the provider's quote timestamp in RFC 3339, empty if unknown
+	LastUpdated string `json:"last_updated,omitempty"`
+	// Source names the provider that supplied the price
+	Source string `json:"source,omitempty"`
+	// Sources lists the providers aggregated into the price, and Spread how far apart they were in percent
+	Sources []string `json:"sources,omitempty"`
+	Spread  float64  `json:"spread,omitempty"`
+	// Status is "ok", or "stale" when the last stored price was reused; StatusReason says why
+	Status       string `json:"status,omitempty"`
+	StatusReason string `json:"status_reason,omitempty"`
+	// Quotes holds the price in each fetched currency, keyed by lower-case code
+	Quotes map[string]QuoteItem `json:"quotes,omitempty"`
+}
+
+// QuoteItem represents a coin price in a single currency
+type QuoteItem struct {
+	Price     float64 `json:"price"`
+	Change24h float64 `json:"change_24h"`
+	MarketCap float64 `json:"market_cap,omitempty"`
+	Volume24h float64 `json:"volume_24h,omitempty"`
 }
 
 // CoinHistory represents the JSON structure for individual coin history
 type CoinHistory struct {
-	ID        string           `json:"id"`
-	Name      string           `json:"name"`
-	Symbol    string           `json:"symbol"`
-	UpdatedAt string           `json:"updated_at"`
-	Current   CryptoDataItem   `json:"current"`
-	History   []PriceDataPoint `json:"history"`
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Symbol    string         `json:"symbol"`
+	UpdatedAt string         `json:"updated_at"`
+	Current   CryptoDataItem `json:"current"`
+	// Resolution is "raw" for every stored sample, or the rollup bucket width such as "1d"
+	Resolution string           `json:"resolution,omitempty"`
+	History    []PriceDataPoint `json:"history"`
 }
 
 // PriceDataPoint represents a single price point in history
@@ -54,19 +89,45 @@ type PriceDataPoint struct {
 type HugoExporter struct {
 	dataPath    string
 	historyPath string
+	candlesPath string
+	clock       domain.Clock
+	resolution  domain.Resolution
 }
 
 // HistoryProvider retrieves price history for coins
 type HistoryProvider interface {
-	GetPriceHistory(coinID string, days int) ([]domain.CryptoPrice, error)
+	GetPriceHistory(coinID string, from, to time.Time, res domain.Resolution) ([]domain.CryptoPrice, error)
+}
+
+// Option configures a HugoExporter
+type Option func(*HugoExporter)
+
+// WithClock sets the clock the export is generated against; the default is the system clock
+func WithClock(clock domain.Clock) Option {
+	return func(e *HugoExporter) {
+		e.clock = clock
+	}
+}
+
+// WithResolution sets the resolution history files are exported at; the default is every raw sample
+func WithResolution(res domain.Resolution) Option {
+	return func(e *HugoExporter) {
+		e.resolution = res
+	}
 }
 
 // NewHugoExporter creates a new Hugo exporter
-func NewHugoExporter(dataPath, historyPath string) *HugoExporter {
-	return &HugoExporter{
+func NewHugoExporter(dataPath, historyPath string, opts ...Option) *HugoExporter {
+	e := &HugoExporter{
 		dataPath:    dataPath,
 		historyPath: historyPath,
+		clock:       domain.SystemClock,
+		resolution:  domain.ResolutionRaw,
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	return e
 }
 
 // ExportAll exports crypto.json and all coin history files
@@ -75,13 +136,21 @@ func (e *HugoExporter) ExportAll(stats []domain.CoinStats, coins []domain.CoinMe
 		return err
 	}
 
+	current := make(map[string]*domain.CoinStats, len(stats))
+	for i, stat := range stats {
+		if stat.Status != domain.StatusMissing {
+			current[stat.Name] = &stats[i]
+		}
+	}
+
+	now := e.clock.Now()
 	for _, coin := range coins {
-		history, err := historyProvider.GetPriceHistory(coin.ID, days)
+		history, err := historyProvider.GetPriceHistory(coin.ID, now.AddDate(0, 0, -days), now, e.resolution)
 		if err != nil {
 			log.Printf("Warning: failed to get history for %s: %v", coin.ID, err)
 			continue
 		}
-		if err := e.ExportCoinHistory(coin, history); err != nil {
+		if err := e.ExportCoinHistory(coin, history, current[coin.ID]); err != nil {
 			log.Printf("Warning: failed to export history for %s: %v", coin.ID, err)
 		}
 	}
@@ -97,22 +166,41 @@ func (e *HugoExporter) ExportCryptoData(stats []domain.CoinStats, coins []domain
 	}
 
 	data := CryptoData{
-		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		UpdatedAt: e.clock.Now().UTC().Format(time.RFC3339),
 		Coins:     make([]CryptoDataItem, 0, len(stats)),
 	}
 
 	for _, stat := range stats {
 		meta := coinMeta[stat.Name]
+		if stat.Status == domain.StatusMissing {
+			data.Missing = append(data.Missing, MissingItem{
+				ID:     stat.Name,
+				Name:   meta.Name,
+				Symbol: stat.Symbol,
+				Reason: stat.StatusReason,
+			})
+			continue
+		}
 		data.Coins = append(data.Coins, CryptoDataItem{
-			ID:          stat.Name,
-			Name:        meta.Name,
-			Symbol:      stat.Symbol,
-			Price:       stat.Price,
-			Change24h:   stat.Change24h,
-			Change7d:    stat.Change7d.PctChange,
-			Change7dOk:  stat.Change7d.HasData,
-			Change30d:   stat.Change30d.PctChange,
-			Change30dOk: stat.Change30d.HasData,
+			ID:           stat.Name,
+			Name:         meta.Name,
+			Symbol:       stat.Symbol,
+			Price:        stat.Price,
+			Change24h:    stat.Change24h,
+			Change24hOk:  stat.HasChange24h,
+			Change7d:     stat.Change7d.PctChange,
+			Change7dOk:   stat.Change7d.HasData,
+			Change30d:    stat.Change30d.PctChange,
+			Change30dOk:  stat.Change30d.HasData,
+			MarketCap:    stat.MarketCap,
+			Volume24h:    stat.Volume24h,
+			LastUpdated:  formatTime(stat.LastUpdated),
+			Source:       stat.Source,
+			Sources:      stat.Sources,
+			Spread:       stat.Spread,
+			Quotes:       quoteItems(stat.Quotes),
+			Status:       string(stat.Status),
+			StatusReason: stat.StatusReason,
 		})
 	}
 
@@ -128,13 +216,26 @@ func (e *HugoExporter) ExportCryptoData(stats []domain.CoinStats, coins []domain
 	return nil
 }
 
-// ExportCoinHistory exports individual history file for a coin
-func (e *HugoExporter) ExportCoinHistory(coin domain.CoinMetadata, history []domain.CryptoPrice) error {
+// ExportCoinHistory exports individual history file for a coin. When stat is not
+// nil, the current price and 24h change are taken from it, so that they match crypto.json.
+func (e *HugoExporter) ExportCoinHistory(coin domain.CoinMetadata, history []domain.CryptoPrice, stat *domain.CoinStats) error {
 	if err := os.MkdirAll(e.historyPath, dirMode); err != nil {
 		return err
 	}
 
-	current, has7d, has30d := calculateChanges(history)
+	now := e.clock.Now()
+	current, has24h, has7d, has30d := calculateChanges(history, now)
+	var latest domain.CryptoPrice
+	if len(history) > 0 {
+		latest = history[len(history)-1]
+	}
+	if stat != nil {
+		current.price = stat.Price
+		current.change24h = stat.Change24h
+		has24h = stat.HasChange24h
+		latest.LastUpdated = stat.LastUpdated
+		latest.Source = stat.Source
+	}
 
 	historyPoints := make([]PriceDataPoint, 0, len(history))
 	for _, h := range history {
@@ -148,19 +249,23 @@ func (e *HugoExporter) ExportCoinHistory(coin domain.CoinMetadata, history []dom
 		ID:        coin.ID,
 		Name:      coin.Name,
 		Symbol:    coin.Symbol,
-		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		UpdatedAt: now.UTC().Format(time.RFC3339),
 		Current: CryptoDataItem{
 			ID:          coin.ID,
 			Name:        coin.Name,
 			Symbol:      coin.Symbol,
 			Price:       current.price,
 			Change24h:   current.change24h,
+			Change24hOk: has24h,
 			Change7d:    current.change7d,
 			Change7dOk:  has7d,
 			Change30d:   current.change30d,
 			Change30dOk: has30d,
+			LastUpdated: formatTime(latest.LastUpdated),
+			Source:      latest.Source,
 		},
-		History: historyPoints,
+		Resolution: string(e.resolution),
+		History:    historyPoints,
 	}
 
 	filePath := filepath.Join(e.historyPath, coin.ID+".json")
@@ -179,27 +284,33 @@ type priceChanges struct {
 	change30d float64
 }
 
-func calculateChanges(history []domain.CryptoPrice) (priceChanges, bool, bool) {
+// calculateChanges derives the changes of the latest price in history relative to now,
+// reporting which of the 24h, 7d and 30d changes are known. The 24h change the
+// provider reported with the latest price is used when it was stored.
+func calculateChanges(history []domain.CryptoPrice, now time.Time) (priceChanges, bool, bool, bool) {
 	var changes priceChanges
 	var has7d, has30d bool
 
 	if len(history) == 0 {
-		return changes, false, false
+		return changes, false, false, false
 	}
 
-	changes.price = history[len(history)-1].PriceUSD
+	latest := history[len(history)-1]
+	changes.price = latest.PriceUSD
+	changes.change24h = latest.Change24h
+	has24h := latest.HasChange24h
 
 	if len(history) < 2 {
-		return changes, false, false
+		return changes, has24h, false, false
 	}
 
-	now := time.Now().UTC()
 	for i := len(history) - 2; i >= 0; i-- {
 		hoursDiff := now.Sub(history[i].FetchedAt).Hours()
 		daysDiff := hoursDiff / 24
 
-		if changes.change24h == 0 && hoursDiff >= 20 {
+		if !has24h && hoursDiff >= 20 {
 			changes.change24h = calcPctChange(changes.price, history[i].PriceUSD)
+			has24h = true
 		}
 		if !has7d && daysDiff >= 6.5 {
 			changes.change7d = calcPctChange(changes.price, history[i].PriceUSD)
@@ -220,7 +331,30 @@ func calculateChanges(history []domain.CryptoPrice) (priceChanges, bool, bool) {
 		}
 	}
 
-	return changes, has7d, has30d
+	return changes, has24h, has7d, has30d
+}
+
+func quoteItems(quotes map[string]domain.Quote) map[string]QuoteItem {
+	if len(quotes) == 0 {
+		return nil
+	}
+	items := make(map[string]QuoteItem, len(quotes))
+	for currency, q := range quotes {
+		items[currency] = QuoteItem{
+			Price:     q.Price,
+			Change24h: q.Change24h,
+			MarketCap: q.MarketCap,
+			Volume24h: q.Volume24h,
+		}
+	}
+	return items
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
 }
 
 func calcPctChange(current, past float64) float64 {
@@ -0,0 +1,117 @@
+package exporter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+func TestCalculateChanges(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	at := func(price float64, ago time.Duration) domain.CryptoPrice {
+		return domain.CryptoPrice{Coin: "bitcoin", PriceUSD: price, FetchedAt: now.Add(-ago)}
+	}
+	const day = 24 * time.Hour
+
+	tests := []struct {
+		name    string
+		history []domain.CryptoPrice
+		want    priceChanges
+		want24h bool
+		want7d  bool
+		want30d bool
+	}{
+		{
+			name: "empty history",
+		},
+		{
+			name:    "single sample",
+			history: []domain.CryptoPrice{at(100, 0)},
+			want:    priceChanges{price: 100},
+		},
+		{
+			name:    "only recent samples",
+			history: []domain.CryptoPrice{at(90, 2*time.Hour), at(100, 0)},
+			want:    priceChanges{price: 100},
+		},
+		{
+			name:    "24h change",
+			history: []domain.CryptoPrice{at(80, day), at(90, 2*time.Hour), at(100, 0)},
+			want:    priceChanges{price: 100, change24h: 25},
+			want24h: true,
+		},
+		{
+			name:    "7d change",
+			history: []domain.CryptoPrice{at(50, 7*day), at(80, day), at(100, 0)},
+			want:    priceChanges{price: 100, change24h: 25, change7d: 100},
+			want24h: true,
+			want7d:  true,
+		},
+		{
+			name:    "30d change",
+			history: []domain.CryptoPrice{at(200, 30*day), at(50, 7*day), at(80, day), at(100, 0)},
+			want:    priceChanges{price: 100, change24h: 25, change7d: 100, change30d: -50},
+			want24h: true,
+			want7d:  true,
+			want30d: true,
+		},
+		{
+			name:    "30d falls back to an oldest sample of 25 days",
+			history: []domain.CryptoPrice{at(125, 26*day), at(100, 0)},
+			want:    priceChanges{price: 100, change24h: -20, change7d: -20, change30d: -20},
+			want24h: true,
+			want7d:  true,
+			want30d: true,
+		},
+		{
+			name:    "oldest sample too recent for 30d",
+			history: []domain.CryptoPrice{at(125, 20*day), at(100, 0)},
+			want:    priceChanges{price: 100, change24h: -20, change7d: -20},
+			want24h: true,
+			want7d:  true,
+		},
+		{
+			name: "stored 24h change",
+			history: []domain.CryptoPrice{
+				at(80, day),
+				{Coin: "bitcoin", PriceUSD: 100, Change24h: 3.5, HasChange24h: true, FetchedAt: now},
+			},
+			want:    priceChanges{price: 100, change24h: 3.5},
+			want24h: true,
+		},
+		{
+			name: "stored zero 24h change",
+			history: []domain.CryptoPrice{
+				at(80, day),
+				{Coin: "bitcoin", PriceUSD: 100, HasChange24h: true, FetchedAt: now},
+			},
+			want:    priceChanges{price: 100},
+			want24h: true,
+		},
+		{
+			name:    "zero past price",
+			history: []domain.CryptoPrice{at(0, day), at(100, 0)},
+			want:    priceChanges{price: 100},
+			want24h: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, has24h, has7d, has30d := calculateChanges(tt.history, now)
+			if has24h != tt.want24h || has7d != tt.want7d || has30d != tt.want30d {
+				t.Errorf("has24h, has7d, has30d = %v, %v, %v, want %v, %v, %v", has24h, has7d, has30d, tt.want24h, tt.want7d, tt.want30d)
+			}
+			if !closeTo(got.price, tt.want.price) || !closeTo(got.change24h, tt.want.change24h) ||
+				!closeTo(got.change7d, tt.want.change7d) || !closeTo(got.change30d, tt.want.change30d) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,238 @@
+// Package fake provides in-memory implementations of the domain interfaces for tests.
+// Every fake records its calls and can be told to fail.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// Fetcher is an in-memory domain.PriceFetcher
+type Fetcher struct {
+	mu sync.Mutex
+	// Prices are returned for the requested coins; coins without an entry are
+	// reported in a *domain.PartialError
+	Prices map[string]domain.CryptoPrice
+	// Err, when set, is returned instead of any prices
+	Err error
+	// Calls records the coin ids of every FetchPrices call
+	Calls [][]string
+}
+
+var _ domain.PriceFetcher = (*Fetcher)(nil)
+
+// FetchPrices returns the configured prices for coinIDs
+func (f *Fetcher) FetchPrices(ctx context.Context, coinIDs []string) (map[string]domain.CryptoPrice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls = append(f.Calls, slices.Clone(coinIDs))
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	prices := make(map[string]domain.CryptoPrice, len(coinIDs))
+	partial := &domain.PartialError{}
+	for _, id := range coinIDs {
+		price, ok := f.Prices[id]
+		if !ok {
+			partial.Missing = append(partial.Missing, domain.MissingPrice{Coin: id, Err: domain.ErrNoData})
+			continue
+		}
+		prices[id] = price
+	}
+	if len(partial.Missing) > 0 {
+		return prices, partial
+	}
+	return prices, nil
+}
+
+// Repository is an in-memory domain.PriceRepository and domain.HistoryWriter
+type Repository struct {
+	mu sync.Mutex
+	// History holds the stored prices per coin, oldest first
+	History map[string][]domain.CryptoPrice
+
+	// Errors injected into the matching methods
+	SaveErr       error
+	LatestErr     error
+	HistoricalErr error
+	HistoryErr    error
+
+	// Saved records the argument of every successful SavePrices call
+	Saved  []map[string]domain.CryptoPrice
+	Closed bool
+}
+
+var (
+	_ domain.PriceRepository = (*Repository)(nil)
+	_ domain.HistoryWriter   = (*Repository)(nil)
+)
+
+// NewRepository creates a repository holding history, which is sorted by FetchedAt
+func NewRepository(history ...domain.CryptoPrice) *Repository {
+	r := &Repository{History: make(map[string][]domain.CryptoPrice)}
+	for _, p := range history {
+		r.add(p)
+	}
+	return r
+}
+
+// add inserts p keeping the coin's history ordered, replacing a sample with the
+// same time and source like the database upsert; the caller holds mu or owns r
+func (r *Repository) add(p domain.CryptoPrice) {
+	if r.History == nil {
+		r.History = make(map[string][]domain.CryptoPrice)
+	}
+	h := r.History[p.Coin]
+	if i := r.find(p); i >= 0 {
+		h[i] = p
+		return
+	}
+	i, _ := slices.BinarySearchFunc(h, p.FetchedAt, func(e domain.CryptoPrice, t time.Time) int {
+		return e.FetchedAt.Compare(t)
+	})
+	r.History[p.Coin] = slices.Insert(h, i, p)
+}
+
+// find returns the index of the stored sample with p's coin, time and source, or -1
+func (r *Repository) find(p domain.CryptoPrice) int {
+	return slices.IndexFunc(r.History[p.Coin], func(e domain.CryptoPrice) bool {
+		return e.FetchedAt.Equal(p.FetchedAt) && e.Source == p.Source
+	})
+}
+
+// SavePrices adds prices to the history
+func (r *Repository) SavePrices(prices map[string]domain.CryptoPrice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.SaveErr != nil {
+		return r.SaveErr
+	}
+	saved := make(map[string]domain.CryptoPrice, len(prices))
+	for id, p := range prices {
+		saved[id] = p
+		r.add(p)
+	}
+	r.Saved = append(r.Saved, saved)
+	return nil
+}
+
+// SavePriceHistory stores samples whose coin, time and source are not stored yet
+func (r *Repository) SavePriceHistory(prices []domain.CryptoPrice) (int, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.SaveErr != nil {
+		return 0, 0, r.SaveErr
+	}
+	inserted, skipped := 0, 0
+	for _, p := range prices {
+		if r.find(p) >= 0 {
+			skipped++
+			continue
+		}
+		r.add(p)
+		inserted++
+	}
+	return inserted, skipped, nil
+}
+
+// GetLatestPrice returns the newest price of a coin stored at or before asOf
+func (r *Repository) GetLatestPrice(coinID string, asOf time.Time) (domain.CryptoPrice, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.LatestErr != nil {
+		return domain.CryptoPrice{}, false, r.LatestErr
+	}
+	p, ok := r.latest(coinID, asOf)
+	return p, ok, nil
+}
+
+// GetHistoricalPrice returns the newest price of a coin stored at or before at
+func (r *Repository) GetHistoricalPrice(coinID string, at time.Time) (float64, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.HistoricalErr != nil {
+		return 0, false, r.HistoricalErr
+	}
+	p, ok := r.latest(coinID, at)
+	return p.PriceUSD, ok, nil
+}
+
+// latest returns the newest price stored at or before at; the caller holds mu
+func (r *Repository) latest(coinID string, at time.Time) (domain.CryptoPrice, bool) {
+	h := r.History[coinID]
+	for i := len(h) - 1; i >= 0; i-- {
+		if !h[i].FetchedAt.After(at) {
+			return h[i], true
+		}
+	}
+	return domain.CryptoPrice{}, false
+}
+
+// GetPriceHistory returns the prices stored between from and to, oldest first.
+// Rollup resolutions keep the last sample of each bucket, as the database does.
+func (r *Repository) GetPriceHistory(coinID string, from, to time.Time, res domain.Resolution) ([]domain.CryptoPrice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.HistoryErr != nil {
+		return nil, r.HistoryErr
+	}
+	history := r.History[coinID]
+	if res != domain.ResolutionRaw {
+		history = nil
+		for _, p := range r.History[coinID] {
+			if n := len(history); n > 0 && res.Bucket(history[n-1].FetchedAt).Equal(res.Bucket(p.FetchedAt)) {
+				history[n-1] = p
+				continue
+			}
+			history = append(history, p)
+		}
+	}
+	var prices []domain.CryptoPrice
+	for _, p := range history {
+		if !p.FetchedAt.Before(from) && !p.FetchedAt.After(to) {
+			prices = append(prices, p)
+		}
+	}
+	return prices, nil
+}
+
+// Close marks the repository closed
+func (r *Repository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Closed = true
+	return nil
+}
+
+// Generator is a domain.ReadmeGenerator that renders one line per coin
+type Generator struct {
+	mu sync.Mutex
+	// Calls records the stats of every Generate call
+	Calls [][]domain.CoinStats
+}
+
+var _ domain.ReadmeGenerator = (*Generator)(nil)
+
+// Generate lists each coin's symbol, status and price
+func (g *Generator) Generate(stats []domain.CoinStats, coins []domain.CoinMetadata) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.Calls = append(g.Calls, slices.Clone(stats))
+
+	var sb strings.Builder
+	for _, s := range stats {
+		fmt.Fprintf(&sb, "%s %s %.2f\n", s.Symbol, s.Status, s.Price)
+	}
+	return sb.String()
+}
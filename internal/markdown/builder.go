@@ -1,20 +1,83 @@
 package markdown
 
 import (
+	"cmp"
 	"fmt"
+	"html"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/viczuno/go-crypto-bot/internal/domain"
 )
 
+// defaultStaleAfter is how old a provider quote may be before it is flagged
+const defaultStaleAfter = time.Hour
+
+// unknownChange is shown in place of a 24h change no provider or stored history reported
+const unknownChange = "—"
+
 // ReadmeBuilder implements domain.ReadmeGenerator
-type ReadmeBuilder struct{}
+type ReadmeBuilder struct {
+	currencies  []string
+	byMarketCap bool
+	staleAfter  time.Duration
+	maxSpread   float64
+	clock       domain.Clock
+}
+
+// Option configures a ReadmeBuilder
+type Option func(*ReadmeBuilder)
+
+// WithCurrencies shows a price column per currency, in order
+func WithCurrencies(currencies ...string) Option {
+	return func(b *ReadmeBuilder) {
+		if len(currencies) > 0 {
+			b.currencies = currencies
+		}
+	}
+}
+
+// WithMarketCapOrder lists coins by descending market cap instead of config order
+func WithMarketCapOrder() Option {
+	return func(b *ReadmeBuilder) {
+		b.byMarketCap = true
+	}
+}
+
+// WithStaleAfter sets how old a provider quote may be before it is flagged as stale
+func WithStaleAfter(d time.Duration) Option {
+	return func(b *ReadmeBuilder) {
+		b.staleAfter = d
+	}
+}
+
+// WithMaxSpread flags coins whose provider quotes differ by more than pct percent
+func WithMaxSpread(pct float64) Option {
+	return func(b *ReadmeBuilder) {
+		b.maxSpread = pct
+	}
+}
+
+// WithClock sets the clock the README is generated against; the default is the system clock
+func WithClock(clock domain.Clock) Option {
+	return func(b *ReadmeBuilder) {
+		b.clock = clock
+	}
+}
 
 // NewReadmeBuilder creates a new README builder
-func NewReadmeBuilder() *ReadmeBuilder {
-	return &ReadmeBuilder{}
+func NewReadmeBuilder(opts ...Option) *ReadmeBuilder {
+	b := &ReadmeBuilder{
+		currencies: []string{domain.BaseCurrency},
+		staleAfter: defaultStaleAfter,
+		clock:      domain.SystemClock,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
 }
 
 var _ domain.ReadmeGenerator = (*ReadmeBuilder)(nil)
@@ -22,11 +85,30 @@ var _ domain.ReadmeGenerator = (*ReadmeBuilder)(nil)
 // Generate creates the README content from coin statistics
 func (b *ReadmeBuilder) Generate(stats []domain.CoinStats, coins []domain.CoinMetadata) string {
 	var sb strings.Builder
-	now := time.Now().UTC()
+	now := b.clock.Now().UTC()
+
+	if b.byMarketCap {
+		stats = slices.Clone(stats)
+		slices.SortStableFunc(stats, func(a, b domain.CoinStats) int {
+			return cmp.Compare(b.MarketCap, a.MarketCap)
+		})
+	}
+
+	// Coins without any price are listed below the table instead of in it
+	priced := make([]domain.CoinStats, 0, len(stats))
+	var missing []domain.CoinStats
+	for _, s := range stats {
+		if s.Status == domain.StatusMissing {
+			missing = append(missing, s)
+			continue
+		}
+		priced = append(priced, s)
+	}
 
 	b.writeHeader(&sb, now)
-	b.writePriceTable(&sb, stats, coins)
-	b.writePerformanceChart(&sb, stats, coins)
+	b.writePriceTable(&sb, priced, coins, now)
+	b.writeMissing(&sb, missing, coins)
+	b.writePerformanceChart(&sb, priced, coins)
 	b.writeFooter(&sb)
 
 	return sb.String()
@@ -76,16 +158,20 @@ func (b *ReadmeBuilder) writeMarketOverview(sb *strings.Builder, stats []domain.
 	sb.WriteString("</tr>\n</table>\n\n")
 }
 
-func (b *ReadmeBuilder) writePriceTable(sb *strings.Builder, stats []domain.CoinStats, coins []domain.CoinMetadata) {
+func (b *ReadmeBuilder) writePriceTable(sb *strings.Builder, stats []domain.CoinStats, coins []domain.CoinMetadata, now time.Time) {
 	sb.WriteString("## 💰 Live Prices & Trends\n\n")
 	sb.WriteString("<table>\n")
 	sb.WriteString("<thead>\n")
 	sb.WriteString("<tr>\n")
 	sb.WriteString("<th align=\"left\">Asset</th>\n")
-	sb.WriteString("<th align=\"right\">Price (USD)</th>\n")
+	for _, cur := range b.currencies {
+		sb.WriteString(fmt.Sprintf("<th align=\"right\">Price (%s)</th>\n", strings.ToUpper(cur)))
+	}
 	sb.WriteString("<th align=\"center\">24h</th>\n")
 	sb.WriteString("<th align=\"center\">7 Days</th>\n")
 	sb.WriteString("<th align=\"center\">30 Days</th>\n")
+	sb.WriteString("<th align=\"right\">Market Cap</th>\n")
+	sb.WriteString("<th align=\"right\">Volume (24h)</th>\n")
 	sb.WriteString("</tr>\n")
 	sb.WriteString("</thead>\n")
 	sb.WriteString("<tbody>\n")
@@ -98,20 +184,24 @@ func (b *ReadmeBuilder) writePriceTable(sb *strings.Builder, stats []domain.Coin
 	for _, s := range stats {
 		meta := coinMap[s.Name]
 
-		// Format price with proper formatting
-		priceStr := b.formatPrice(s.Price)
-
 		// Format changes
-		change24h := b.formatChangeWithColor(s.Change24h)
+		change24h := unknownChange
+		if s.HasChange24h {
+			change24h = b.formatChangeWithColor(s.Change24h)
+		}
 		change7d := b.formatHistoricalChange(s.Change7d)
 		change30d := b.formatHistoricalChange(s.Change30d)
 
 		sb.WriteString("<tr>\n")
-		sb.WriteString(fmt.Sprintf("<td><b>%s %s</b><br/></td>\n", meta.Name, meta.Symbol))
-		sb.WriteString(fmt.Sprintf("<td align=\"right\"><code>%s</code></td>\n", priceStr))
+		sb.WriteString(fmt.Sprintf("<td><b>%s %s</b><br/>%s%s%s</td>\n", meta.Name, meta.Symbol, b.formatStatus(s), b.formatFreshness(s, now), b.formatSpread(s)))
+		for _, cur := range b.currencies {
+			sb.WriteString(fmt.Sprintf("<td align=\"right\"><code>%s</code></td>\n", b.formatQuote(s, cur)))
+		}
 		sb.WriteString(fmt.Sprintf("<td align=\"center\">%s</td>\n", change24h))
 		sb.WriteString(fmt.Sprintf("<td align=\"center\">%s</td>\n", change7d))
 		sb.WriteString(fmt.Sprintf("<td align=\"center\">%s</td>\n", change30d))
+		sb.WriteString(fmt.Sprintf("<td align=\"right\">%s</td>\n", b.formatCompact(s.MarketCap)))
+		sb.WriteString(fmt.Sprintf("<td align=\"right\">%s</td>\n", b.formatCompact(s.Volume24h)))
 		sb.WriteString("</tr>\n")
 	}
 
@@ -119,6 +209,26 @@ func (b *ReadmeBuilder) writePriceTable(sb *strings.Builder, stats []domain.Coin
 	sb.WriteString("</table>\n\n")
 }
 
+// writeMissing lists the coins that could not be priced and why
+func (b *ReadmeBuilder) writeMissing(sb *strings.Builder, missing []domain.CoinStats, coins []domain.CoinMetadata) {
+	if len(missing) == 0 {
+		return
+	}
+
+	coinMap := make(map[string]domain.CoinMetadata)
+	for _, c := range coins {
+		coinMap[c.ID] = c
+	}
+
+	sb.WriteString("> [!WARNING]\n")
+	sb.WriteString("> No price available for:\n")
+	for _, s := range missing {
+		meta := coinMap[s.Name]
+		sb.WriteString(fmt.Sprintf("> - **%s %s**: %s\n", meta.Name, meta.Symbol, s.StatusReason))
+	}
+	sb.WriteString("\n")
+}
+
 func (b *ReadmeBuilder) writePerformanceChart(sb *strings.Builder, stats []domain.CoinStats, coins []domain.CoinMetadata) {
 	coinMap := make(map[string]domain.CoinMetadata)
 	for _, c := range coins {
@@ -130,6 +240,9 @@ func (b *ReadmeBuilder) writePerformanceChart(sb *strings.Builder, stats []domai
 	var colors []string
 
 	for _, s := range stats {
+		if !s.HasChange24h {
+			continue
+		}
 		meta := coinMap[s.Name]
 		labels = append(labels, fmt.Sprintf("'%s'", meta.Symbol))
 		data = append(data, fmt.Sprintf("%.2f", s.Change24h))
@@ -199,6 +312,93 @@ func (b *ReadmeBuilder) formatPrice(price float64) string {
 	return fmt.Sprintf("$%.4f", price)
 }
 
+// formatCompact formats large USD amounts such as market caps as $1.35T or $245.10B
+func (b *ReadmeBuilder) formatCompact(amount float64) string {
+	switch {
+	case amount <= 0:
+		return "—"
+	case amount >= 1e12:
+		return fmt.Sprintf("$%.2fT", amount/1e12)
+	case amount >= 1e9:
+		return fmt.Sprintf("$%.2fB", amount/1e9)
+	case amount >= 1e6:
+		return fmt.Sprintf("$%.2fM", amount/1e6)
+	default:
+		return fmt.Sprintf("$%.0f", amount)
+	}
+}
+
+// formatStatus marks stale prices that were reused because the latest fetch failed
+func (b *ReadmeBuilder) formatStatus(s domain.CoinStats) string {
+	if s.Status != domain.StatusStale {
+		return ""
+	}
+	return fmt.Sprintf("<sub title=\"%s\">🕒 last known price</sub>", html.EscapeString(s.StatusReason))
+}
+
+// formatFreshness flags quotes whose provider timestamp is older than staleAfter
+func (b *ReadmeBuilder) formatFreshness(s domain.CoinStats, now time.Time) string {
+	if s.LastUpdated.IsZero() || b.staleAfter <= 0 {
+		return ""
+	}
+	age := now.Sub(s.LastUpdated)
+	if age <= b.staleAfter {
+		return ""
+	}
+	switch {
+	case age >= 48*time.Hour:
+		return fmt.Sprintf("<sub>⏳ quote %dd old</sub>", int(age.Hours()/24))
+	case age >= time.Hour:
+		return fmt.Sprintf("<sub>⏳ quote %dh old</sub>", int(age.Hours()))
+	default:
+		return fmt.Sprintf("<sub>⏳ quote %dm old</sub>", int(age.Minutes()))
+	}
+}
+
+// formatSpread flags coins whose sources disagree by more than maxSpread percent
+func (b *ReadmeBuilder) formatSpread(s domain.CoinStats) string {
+	if b.maxSpread <= 0 || s.Spread <= b.maxSpread {
+		return ""
+	}
+	return fmt.Sprintf("<sub title=\"%s\">⚠️ sources differ %.1f%%</sub>", strings.Join(s.Sources, ", "), s.Spread)
+}
+
+// currencySymbols maps quote currencies to the symbol printed before the price
+var currencySymbols = map[string]string{
+	"usd": "$",
+	"eur": "€",
+	"gbp": "£",
+	"jpy": "¥",
+	"btc": "₿",
+	"eth": "Ξ",
+}
+
+// formatQuote formats the coin price in the given currency
+func (b *ReadmeBuilder) formatQuote(s domain.CoinStats, currency string) string {
+	if currency == domain.BaseCurrency {
+		return b.formatPrice(s.Price)
+	}
+
+	quote, ok := s.Quotes[currency]
+	if !ok {
+		return "—"
+	}
+
+	symbol, ok := currencySymbols[currency]
+	if !ok {
+		symbol = strings.ToUpper(currency) + " "
+	}
+
+	switch {
+	case quote.Price >= 1:
+		return fmt.Sprintf("%s%.2f", symbol, quote.Price)
+	case quote.Price >= 0.01:
+		return fmt.Sprintf("%s%.4f", symbol, quote.Price)
+	default:
+		return fmt.Sprintf("%s%.8f", symbol, quote.Price)
+	}
+}
+
 func (b *ReadmeBuilder) formatChangeWithColor(change float64) string {
 	if change > 0 {
 		return fmt.Sprintf("🟢 +%.2f%%", change)
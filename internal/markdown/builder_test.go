@@ -0,0 +1,100 @@
+package markdown
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var goldenCoins = []domain.CoinMetadata{
+	{ID: "bitcoin", Name: "Bitcoin", Symbol: "BTC"},
+	{ID: "ethereum", Name: "Ethereum", Symbol: "ETH"},
+	{ID: "dogecoin", Name: "Dogecoin", Symbol: "DOGE"},
+}
+
+func goldenStats(now time.Time) []domain.CoinStats {
+	return []domain.CoinStats{
+		{
+			Name: "bitcoin", Symbol: "BTC", Price: 64250.5, Change24h: 2.35, HasChange24h: true,
+			MarketCap: 1.27e12, Volume24h: 3.1e10, LastUpdated: now.Add(-5 * time.Minute),
+			Change7d:  domain.PriceChange{PastPrice: 60000, CurrentPrice: 64250.5, AbsChange: 4250.5, PctChange: 7.08, HasData: true, Days: 7},
+			Change30d: domain.PriceChange{Days: 30},
+			Quotes: map[string]domain.Quote{
+				"usd": {Price: 64250.5, Change24h: 2.35},
+				"eur": {Price: 59100.25, Change24h: 2.1},
+			},
+			Source: "coingecko", Status: domain.StatusOK,
+		},
+		{
+			Name: "ethereum", Symbol: "ETH", Price: 3120.75, Change24h: -1.2, HasChange24h: true,
+			MarketCap: 3.75e11, Volume24h: 1.5e10, LastUpdated: now.Add(-3*time.Hour - time.Minute),
+			Change7d:  domain.PriceChange{PastPrice: 3300, CurrentPrice: 3120.75, AbsChange: -179.25, PctChange: -5.43, HasData: true, Days: 7},
+			Change30d: domain.PriceChange{PastPrice: 2800, CurrentPrice: 3120.75, AbsChange: 320.75, PctChange: 11.46, HasData: true, Days: 30},
+			Quotes: map[string]domain.Quote{
+				"usd": {Price: 3120.75, Change24h: -1.2},
+			},
+			Sources: []string{"coingecko", "binance", "kraken"}, Spread: 2.4,
+			Source: "median", Status: domain.StatusOK,
+		},
+		{
+			Name: "dogecoin", Symbol: "DOGE", Price: 0.1234, Change24h: 0.5,
+			MarketCap: 1.8e10, Volume24h: 9e8,
+			Change7d:  domain.PriceChange{Days: 7},
+			Change30d: domain.PriceChange{Days: 30},
+			Source:    "coingecko", Status: domain.StatusStale, StatusReason: "coingecko: rate limited",
+		},
+	}
+}
+
+func TestGenerateGolden(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	missing := domain.CoinStats{Name: "dogecoin", Symbol: "DOGE", Status: domain.StatusMissing, StatusReason: "coingecko: no price data"}
+
+	tests := []struct {
+		name  string
+		opts  []Option
+		stats []domain.CoinStats
+	}{
+		{
+			name:  "default",
+			stats: goldenStats(now),
+		},
+		{
+			name:  "currencies_by_market_cap",
+			opts:  []Option{WithCurrencies("usd", "eur"), WithMarketCapOrder()},
+			stats: goldenStats(now),
+		},
+		{
+			name:  "spread_and_missing",
+			opts:  []Option{WithMaxSpread(1), WithStaleAfter(0)},
+			stats: append(goldenStats(now)[:2], missing),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithClock(domain.FixedClock(now))}, tt.opts...)
+			got := NewReadmeBuilder(opts...).Generate(tt.stats, goldenCoins)
+
+			path := filepath.Join("testdata", tt.name+".golden.md")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("README differs from %s; run go test ./internal/markdown -update and review the diff\ngot:\n%s", path, got)
+			}
+		})
+	}
+}
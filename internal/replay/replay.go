@@ -0,0 +1,175 @@
+// Package replay records HTTP responses to fixture files and serves them back,
+// so the API clients can run offline and deterministically.
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// fileMode is the permission used for fixture files
+const fileMode = 0644
+
+// ErrNoFixture is returned when replaying a request that was never recorded
+var ErrNoFixture = errors.New("no recorded fixture")
+
+// Fixture is a recorded response as stored on disk
+type Fixture struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+	// Header keeps only the response headers the clients read
+	Header map[string]string `json:"header,omitempty"`
+	Body   json.RawMessage   `json:"body,omitempty"`
+	// Text holds bodies that are not valid JSON
+	Text string `json:"text,omitempty"`
+}
+
+// keptHeaders are the response headers stored in fixtures
+var keptHeaders = []string{"Content-Type", "Retry-After"}
+
+// Recorder is an http.RoundTripper that forwards requests and saves every response.
+// Request headers, and with them any API keys, are never written.
+type Recorder struct {
+	dir  string
+	next http.RoundTripper
+	mu   sync.Mutex
+}
+
+// NewRecorder records responses from next into dir; a nil next uses http.DefaultTransport
+func NewRecorder(dir string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{dir: dir, next: next}
+}
+
+// RoundTrip performs the request and records the response
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response for recording: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	fixture := Fixture{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Status: resp.StatusCode,
+		Header: make(map[string]string),
+	}
+	for _, h := range keptHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			fixture.Header[h] = v
+		}
+	}
+	if json.Valid(body) {
+		fixture.Body = body
+	} else {
+		fixture.Text = string(body)
+	}
+
+	if err := r.save(req, fixture); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *Recorder) save(req *http.Request, fixture Fixture) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, fileName(req)), data, fileMode); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// Replayer is an http.RoundTripper that answers requests from recorded fixtures
+type Replayer struct {
+	dir string
+}
+
+// NewReplayer serves the fixtures recorded in dir
+func NewReplayer(dir string) *Replayer {
+	return &Replayer{dir: dir}
+}
+
+// RoundTrip returns the recorded response for the request
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := fileName(req)
+	data, err := os.ReadFile(filepath.Join(r.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s %s (%s)", ErrNoFixture, req.Method, req.URL.Redacted(), name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", name, err)
+	}
+
+	body := []byte(fixture.Body)
+	if fixture.Text != "" {
+		body = []byte(fixture.Text)
+	}
+	header := make(http.Header)
+	for k, v := range fixture.Header {
+		header.Set(k, v)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.Status, http.StatusText(fixture.Status)),
+		StatusCode:    fixture.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// fileName derives a stable, readable fixture name from the method, host, path and query
+func fileName(req *http.Request) string {
+	u := req.URL
+	sum := sha256.Sum256([]byte(req.Method + " " + u.Host + u.EscapedPath() + "?" + u.Query().Encode()))
+
+	readable := u.Host + "_" + strings.Trim(u.Path, "/")
+	readable = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, readable)
+
+	return fmt.Sprintf("%s_%s.json", readable, hex.EncodeToString(sum[:4]))
+}
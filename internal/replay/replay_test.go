@@ -0,0 +1,156 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/api"
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// upstream answers JSON on /json, plain text on /text and a rate limit on /limited
+func upstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"a":%q,"b":%q}`, r.URL.Query().Get("a"), r.URL.Query().Get("b"))
+		case "/limited":
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = fmt.Fprint(w, "slow down")
+		default:
+			_, _ = fmt.Fprint(w, "plain text")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type response struct {
+	status     int
+	retryAfter string
+	body       string
+}
+
+func get(t *testing.T, client *http.Client, url string) (response, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("x-cg-pro-api-key", "secret-key")
+	resp, err := client.Do(req)
+	if err != nil {
+		return response{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fixtures store JSON bodies indented
+	var compact bytes.Buffer
+	if json.Compact(&compact, body) == nil {
+		body = compact.Bytes()
+	}
+	return response{status: resp.StatusCode, retryAfter: resp.Header.Get("Retry-After"), body: string(body)}, nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	srv := upstream(t)
+	dir := t.TempDir()
+
+	paths := []string{"/json?a=1&b=2", "/text", "/limited"}
+	recorder := &http.Client{Transport: NewRecorder(dir, nil)}
+	recorded := make(map[string]response, len(paths))
+	for _, path := range paths {
+		resp, err := get(t, recorder, srv.URL+path)
+		if err != nil {
+			t.Fatalf("recording %s: %v", path, err)
+		}
+		recorded[path] = resp
+	}
+
+	// Fixtures never hold request headers such as API keys
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(paths) {
+		t.Errorf("recorded %d fixtures, want %d", len(files), len(paths))
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "secret-key") {
+			t.Errorf("fixture %s contains the API key", f.Name())
+		}
+	}
+
+	// Replay works without the server, whatever the query parameter order
+	srv.Close()
+	replayer := &http.Client{Transport: NewReplayer(dir)}
+	replayed := map[string]string{
+		"/json?a=1&b=2": "/json?b=2&a=1",
+		"/text":         "/text",
+		"/limited":      "/limited",
+	}
+	for path, replayPath := range replayed {
+		got, err := get(t, replayer, srv.URL+replayPath)
+		if err != nil {
+			t.Fatalf("replaying %s: %v", replayPath, err)
+		}
+		if want := recorded[path]; got != want {
+			t.Errorf("replayed %s = %+v, want the recorded %+v", replayPath, got, want)
+		}
+	}
+
+	if _, err := get(t, replayer, srv.URL+"/json?a=3"); !errors.Is(err, ErrNoFixture) {
+		t.Errorf("unrecorded request error = %v, want ErrNoFixture", err)
+	}
+}
+
+func TestReplayCoinGeckoPrices(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"bitcoin":{"usd":68000,"usd_24h_change":1.5,"usd_market_cap":1.3e12,"eur":62000,"last_updated_at":1773057600}}`)
+	}))
+	dir := t.TempDir()
+	fetch := func(rt http.RoundTripper) (domain.CryptoPrice, error) {
+		client := api.NewCoinGeckoClient(api.WithBaseURL(srv.URL), api.WithCurrencies("eur"), api.WithTransport(rt))
+		prices, err := client.FetchPrices(context.Background(), []string{"bitcoin"})
+		// Only the fetch time differs between runs
+		p := prices["bitcoin"]
+		p.FetchedAt = time.Time{}
+		return p, err
+	}
+
+	want, err := fetch(NewRecorder(dir, nil))
+	if err != nil {
+		t.Fatalf("recording: %v", err)
+	}
+	srv.Close()
+
+	got, err := fetch(NewReplayer(dir))
+	if err != nil {
+		t.Fatalf("replaying: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed prices = %+v, want the recorded %+v", got, want)
+	}
+}
@@ -0,0 +1,196 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes when a job should next run
+type Schedule interface {
+	// Next returns the first run strictly after the given time, or the zero time if there is none
+	Next(after time.Time) time.Time
+}
+
+// Interval runs a job at a fixed period
+type Interval time.Duration
+
+// Next returns after plus the interval
+func (i Interval) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(i))
+}
+
+// Parse builds a Schedule from "@every <duration>", one of the
+// @hourly/@daily/@weekly/@monthly shorthands, or a five-field cron expression
+// (minute hour day-of-month month day-of-week) evaluated in UTC.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval %q: %w", spec, err)
+		}
+		if d < time.Minute {
+			return nil, fmt.Errorf("invalid interval %q: must be at least 1m", spec)
+		}
+		return Interval(d), nil
+	}
+
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	}
+
+	c, err := parseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+	if c.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("invalid cron expression %q: never fires", spec)
+	}
+	return c, nil
+}
+
+// cronSchedule holds the allowed values of each cron field as bit sets.
+// domStar and dowStar mark day fields written as the whole range, e.g. "*" or "*/2".
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+type fieldRange struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []fieldRange{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+func parseCron(spec string) (*cronSchedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", spec, len(parts))
+	}
+
+	sets := make([]uint64, len(parts))
+	stars := make([]bool, len(parts))
+	for i, part := range parts {
+		set, star, err := parseField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+		}
+		sets[i], stars[i] = set, star
+	}
+
+	return &cronSchedule{
+		minute:  sets[0],
+		hour:    sets[1],
+		dom:     sets[2],
+		month:   sets[3],
+		dow:     sets[4],
+		domStar: stars[2],
+		dowStar: stars[4],
+	}, nil
+}
+
+// parseField parses a comma-separated list of values, ranges and steps such as
+// "0,12" or "*/15". star reports whether every item spans the whole range before
+// any step is applied, as "*" and "*/2" do.
+func parseField(field string, r fieldRange) (set uint64, star bool, err error) {
+	star = true
+	for _, item := range strings.Split(field, ",") {
+		expr, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, false, fmt.Errorf("%s: invalid step %q", r.name, stepStr)
+			}
+			step = n
+		}
+
+		lo, hi := r.min, r.max
+		if expr != "*" {
+			loStr, hiStr, isRange := strings.Cut(expr, "-")
+			var err error
+			if lo, err = strconv.Atoi(loStr); err != nil {
+				return 0, false, fmt.Errorf("%s: invalid value %q", r.name, loStr)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiStr); err != nil {
+					return 0, false, fmt.Errorf("%s: invalid value %q", r.name, hiStr)
+				}
+			} else if hasStep {
+				hi = r.max
+			}
+		}
+
+		if lo < r.min || hi > r.max || lo > hi {
+			return 0, false, fmt.Errorf("%s: %q out of range %d-%d", r.name, item, r.min, r.max)
+		}
+		star = star && lo == r.min && hi == r.max
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, star, nil
+}
+
+// Next returns the first matching minute strictly after the given time
+func (c *cronSchedule) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+
+	// Any day that exists recurs within 40 years, the longest wait for a
+	// February 29 on a given weekday; an expression without one never fires
+	limit := t.AddDate(40, 0, 0)
+	for t.Before(limit) {
+		if !has(c.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !has(c.hour, t.Hour()) {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if !has(c.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay applies the cron rule that day-of-month and day-of-week are OR-ed
+// together when both are restricted. When either is written as the whole range,
+// both must match, so "*/2" in one field still narrows the days of the other.
+func (c *cronSchedule) matchDay(t time.Time) bool {
+	domOK := has(c.dom, t.Day())
+	dowOK := has(c.dow, int(t.Weekday()))
+	if c.domStar || c.dowStar {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{name: "interval", spec: "@every 30m"},
+		{name: "shorthand", spec: "@daily"},
+		{name: "cron", spec: "*/15 8-18 * * 1-5"},
+		{name: "list and step", spec: "0,30 */6 1,15 * *"},
+		{name: "leap day", spec: "0 0 29 2 *"},
+		{name: "interval too short", spec: "@every 30s", wantErr: true},
+		{name: "bad interval", spec: "@every soon", wantErr: true},
+		{name: "too few fields", spec: "0 0 * *", wantErr: true},
+		{name: "out of range", spec: "60 * * * *", wantErr: true},
+		{name: "reversed range", spec: "0 10-8 * * *", wantErr: true},
+		{name: "zero step", spec: "*/0 * * * *", wantErr: true},
+		{name: "bad value", spec: "0 0 x * *", wantErr: true},
+		{name: "never fires", spec: "0 0 31 2 *", wantErr: true},
+		{name: "never fires with stepped weekday", spec: "0 0 30 2 */2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2026-03-14 is a Saturday
+	after := time.Date(2026, 3, 14, 12, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{
+			name: "every 15 minutes",
+			spec: "*/15 * * * *",
+			want: time.Date(2026, 3, 14, 12, 15, 0, 0, time.UTC),
+		},
+		{
+			name: "hourly",
+			spec: "0 * * * *",
+			want: time.Date(2026, 3, 14, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "strictly after a matching minute",
+			spec:  "0 * * * *",
+			after: time.Date(2026, 3, 14, 13, 0, 0, 0, time.UTC),
+			want:  time.Date(2026, 3, 14, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "weekly on Monday",
+			spec: "0 0 * * 1",
+			want: time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "next leap day",
+			spec: "0 0 29 2 *",
+			want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day of month and week both restricted match either",
+			spec: "0 0 20 * 1",
+			want: time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "stepped day of month narrows day of week",
+			spec: "0 0 */2 * 1",
+			// Monday the 16th is an even day, so the first match is the 23rd
+			want: time.Date(2026, 3, 23, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "stepped day of week narrows day of month",
+			spec: "0 0 1 * */2",
+			// The first of the month falls on an even weekday next on Saturday, August 1
+			want: time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "never fires",
+			spec: "0 0 31 2 *",
+			want: time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := parseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("parseCron(%q): %v", tt.spec, err)
+			}
+			from := tt.after
+			if from.IsZero() {
+				from = after
+			}
+			if got := c.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", from, got, tt.want)
+			}
+		})
+	}
+}
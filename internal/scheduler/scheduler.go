@@ -0,0 +1,210 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Job is a named task run on a schedule
+type Job struct {
+	Name     string
+	Schedule Schedule
+	// Jitter delays each run by a random amount up to this duration
+	Jitter time.Duration
+	// Timeout bounds a single run; zero means no limit
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// Scheduler runs jobs one at a time and remembers their last runs in a state file
+type Scheduler struct {
+	jobs      []*entry
+	statePath string
+	now       func() time.Time
+}
+
+type entry struct {
+	job     Job
+	lastRun time.Time
+	next    time.Time
+}
+
+// state is the on-disk record of completed runs, used to catch up after downtime
+type state struct {
+	LastRun map[string]time.Time `json:"last_run"`
+}
+
+// New creates a scheduler persisting its state at statePath; an empty path disables catch-up
+func New(statePath string) *Scheduler {
+	return &Scheduler{
+		statePath: statePath,
+		now:       time.Now,
+	}
+}
+
+// Add registers a job; jobs due at the same moment run in the order they were added
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, &entry{job: job})
+}
+
+// Run executes jobs until ctx is cancelled. A job that is already running when
+// ctx is cancelled is allowed to finish so that its writes are not cut short.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if len(s.jobs) == 0 {
+		return errors.New("no jobs scheduled")
+	}
+
+	st, err := s.loadState()
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	for _, e := range s.jobs {
+		e.lastRun = st.LastRun[e.job.Name]
+		if e.next, err = s.firstRun(e, now); err != nil {
+			return err
+		}
+		log.Printf("Scheduled %s, next run at %s", e.job.Name, e.next.Format(time.RFC3339))
+	}
+
+	for {
+		e := s.nextDue()
+		wait := e.next.Sub(s.now())
+
+		timer := time.NewTimer(max(wait, 0))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Println("Scheduler stopped")
+			return nil
+		case <-timer.C:
+		}
+
+		s.runJob(ctx, e)
+
+		e.lastRun = s.now()
+		st.LastRun[e.job.Name] = e.lastRun
+		if err := s.saveState(st); err != nil {
+			log.Printf("Warning: failed to save scheduler state: %v", err)
+		}
+
+		if e.next, err = s.nextRun(e, e.lastRun); err != nil {
+			return err
+		}
+		log.Printf("Next %s run at %s", e.job.Name, e.next.Format(time.RFC3339))
+	}
+}
+
+// firstRun decides when a job first runs after startup. A job whose scheduled
+// time passed while the daemon was down runs once immediately.
+func (s *Scheduler) firstRun(e *entry, now time.Time) (time.Time, error) {
+	if !e.lastRun.IsZero() {
+		if missed := e.job.Schedule.Next(e.lastRun); !missed.IsZero() && !missed.After(now) {
+			log.Printf("Catching up on %s, missed run at %s", e.job.Name, missed.Format(time.RFC3339))
+			return now, nil
+		}
+	}
+	return s.nextRun(e, now)
+}
+
+// nextRun returns the jittered time of a job's first run after the given time.
+// A schedule with no such run is an error, as running the job at once would spin.
+func (s *Scheduler) nextRun(e *entry, after time.Time) (time.Time, error) {
+	next := e.job.Schedule.Next(after)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("job %s: schedule never fires after %s", e.job.Name, after.Format(time.RFC3339))
+	}
+	return s.jitter(e.job, next), nil
+}
+
+func (s *Scheduler) nextDue() *entry {
+	due := s.jobs[0]
+	for _, e := range s.jobs[1:] {
+		if e.next.Before(due.next) {
+			due = e
+		}
+	}
+	return due
+}
+
+func (s *Scheduler) jitter(job Job, t time.Time) time.Time {
+	if job.Jitter <= 0 {
+		return t
+	}
+	return t.Add(rand.N(job.Jitter))
+}
+
+func (s *Scheduler) runJob(ctx context.Context, e *entry) {
+	// Detach from shutdown so an in-flight run completes; the job timeout still applies
+	runCtx := context.WithoutCancel(ctx)
+	if e.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, e.job.Timeout)
+		defer cancel()
+	}
+
+	log.Printf("Running %s...", e.job.Name)
+	start := s.now()
+	if err := e.job.Run(runCtx); err != nil {
+		log.Printf("Job %s failed after %s: %v", e.job.Name, s.now().Sub(start).Round(time.Millisecond), err)
+		return
+	}
+	log.Printf("Job %s finished in %s", e.job.Name, s.now().Sub(start).Round(time.Millisecond))
+}
+
+func (s *Scheduler) loadState() (*state, error) {
+	st := &state{LastRun: make(map[string]time.Time)}
+	if s.statePath == "" {
+		return st, nil
+	}
+
+	data, err := os.ReadFile(s.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scheduler state: %w", err)
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("failed to parse scheduler state %s: %w", s.statePath, err)
+	}
+	if st.LastRun == nil {
+		st.LastRun = make(map[string]time.Time)
+	}
+	return st, nil
+}
+
+// saveState writes the state atomically so a crash never leaves a truncated file
+func (s *Scheduler) saveState(st *state) error {
+	if s.statePath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.statePath), ".scheduler-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.statePath)
+}
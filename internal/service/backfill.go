@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+)
+
+// maxHourlyWindow is the longest range for which CoinGecko still returns hourly points
+const maxHourlyWindow = 90 * 24 * time.Hour
+
+// BackfillResult summarizes a backfill run for one coin
+type BackfillResult struct {
+	Coin     string
+	Fetched  int
+	Inserted int
+	Skipped  int
+}
+
+// HistoryStore is the part of the repository a backfill reads and writes
+type HistoryStore interface {
+	domain.HistoryWriter
+	GetPriceHistory(coinID string, from, to time.Time, res domain.Resolution) ([]domain.CryptoPrice, error)
+}
+
+// Backfiller loads historical prices into the repository without duplicating existing samples
+type Backfiller struct {
+	fetcher domain.HistoryFetcher
+	repo    HistoryStore
+}
+
+// NewBackfiller creates a new backfiller
+func NewBackfiller(fetcher domain.HistoryFetcher, repo HistoryStore) *Backfiller {
+	return &Backfiller{
+		fetcher: fetcher,
+		repo:    repo,
+	}
+}
+
+// Backfill fetches prices for coinID between from and to, keeps one sample per
+// interval (aligned to the interval start) and stores those of the intervals that
+// hold no stored sample yet. The others count as skipped, so a backfill next to
+// live samples, which are not aligned, does not duplicate them.
+func (b *Backfiller) Backfill(ctx context.Context, coinID string, from, to time.Time, interval time.Duration) (BackfillResult, error) {
+	result := BackfillResult{Coin: coinID}
+	if !from.Before(to) {
+		return result, fmt.Errorf("invalid range: %s is not before %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	window := to.Sub(from)
+	if interval < 24*time.Hour {
+		window = maxHourlyWindow
+	}
+
+	for start := from; start.Before(to); start = start.Add(window) {
+		end := start.Add(window)
+		if end.After(to) {
+			end = to
+		}
+
+		log.Printf("Backfilling %s from %s to %s...", coinID, start.Format(time.DateOnly), end.Format(time.DateOnly))
+		prices, err := b.fetcher.FetchHistoricalRange(ctx, coinID, start, end)
+		if err != nil {
+			return result, fmt.Errorf("failed to fetch history for %s: %w", coinID, err)
+		}
+
+		samples := downsample(prices, interval)
+		result.Fetched += len(samples)
+
+		stored, err := b.storedBuckets(coinID, start, end, interval)
+		if err != nil {
+			return result, err
+		}
+		fresh := samples[:0]
+		for _, p := range samples {
+			if stored[p.FetchedAt] {
+				result.Skipped++
+				continue
+			}
+			fresh = append(fresh, p)
+		}
+
+		inserted, skipped, err := b.repo.SavePriceHistory(fresh)
+		if err != nil {
+			return result, fmt.Errorf("failed to save history for %s: %w", coinID, err)
+		}
+		result.Inserted += inserted
+		result.Skipped += skipped
+	}
+
+	return result, nil
+}
+
+// storedBuckets returns the starts of the intervals covering from to to that
+// already hold a stored sample of coinID
+func (b *Backfiller) storedBuckets(coinID string, from, to time.Time, interval time.Duration) (map[time.Time]bool, error) {
+	start, end := from.UTC().Truncate(interval), to.UTC().Truncate(interval).Add(interval)
+	stored, err := b.repo.GetPriceHistory(coinID, start, end, domain.ResolutionRaw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored history for %s: %w", coinID, err)
+	}
+	buckets := make(map[time.Time]bool, len(stored))
+	for _, p := range stored {
+		buckets[p.FetchedAt.UTC().Truncate(interval)] = true
+	}
+	return buckets, nil
+}
+
+// downsample keeps the first price in each interval and stamps it with the interval start,
+// so repeated backfills produce identical timestamps
+func downsample(prices []domain.CryptoPrice, interval time.Duration) []domain.CryptoPrice {
+	samples := make([]domain.CryptoPrice, 0, len(prices))
+	var last time.Time
+	for _, p := range prices {
+		bucket := p.FetchedAt.UTC().Truncate(interval)
+		if len(samples) > 0 && bucket.Equal(last) {
+			continue
+		}
+		p.FetchedAt = bucket
+		samples = append(samples, p)
+		last = bucket
+	}
+	return samples
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+	"github.com/viczuno/go-crypto-bot/internal/fake"
+)
+
+func TestBackfillSkipsStoredIntervals(t *testing.T) {
+	day := 24 * time.Hour
+	from := testNow.Truncate(day).AddDate(0, 0, -3)
+	to := from.Add(3 * day)
+
+	fetcher := historyFetcher(func(ctx context.Context, coinID string, from, to time.Time) ([]domain.CryptoPrice, error) {
+		var prices []domain.CryptoPrice
+		for t := from.Add(time.Hour); t.Before(to); t = t.Add(6 * time.Hour) {
+			prices = append(prices, price(coinID, 100, t))
+		}
+		return prices, nil
+	})
+	// A live sample a few seconds into the second day
+	live := price("bitcoin", 101, from.Add(day+5*time.Second))
+	repo := fake.NewRepository(live)
+
+	result, err := NewBackfiller(fetcher, repo).Backfill(context.Background(), "bitcoin", from, to, day)
+	if err != nil {
+		t.Fatalf("Backfill: %v", err)
+	}
+	if result.Fetched != 3 || result.Inserted != 2 || result.Skipped != 1 {
+		t.Errorf("result = %+v, want 3 fetched, 2 inserted, 1 skipped", result)
+	}
+
+	history := repo.History["bitcoin"]
+	if len(history) != 3 {
+		t.Fatalf("stored %d samples, want 3", len(history))
+	}
+	if got := history[1]; !got.FetchedAt.Equal(live.FetchedAt) {
+		t.Errorf("second day holds the sample at %s, want the live one at %s", got.FetchedAt, live.FetchedAt)
+	}
+
+	// A second run stores nothing new
+	result, err = NewBackfiller(fetcher, repo).Backfill(context.Background(), "bitcoin", from, to, day)
+	if err != nil {
+		t.Fatalf("Backfill: %v", err)
+	}
+	if result.Inserted != 0 || result.Skipped != 3 {
+		t.Errorf("rerun result = %+v, want 0 inserted, 3 skipped", result)
+	}
+}
+
+// historyFetcher adapts a function to domain.HistoryFetcher
+type historyFetcher func(ctx context.Context, coinID string, from, to time.Time) ([]domain.CryptoPrice, error)
+
+func (f historyFetcher) FetchHistoricalRange(ctx context.Context, coinID string, from, to time.Time) ([]domain.CryptoPrice, error) {
+	return f(ctx, coinID, from, to)
+}
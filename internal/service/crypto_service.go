@@ -2,17 +2,51 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/viczuno/go-crypto-bot/internal/domain"
 )
 
+// MissingPolicy decides what happens to coins the fetcher could not price
+type MissingPolicy string
+
+// Missing price policies
+const (
+	// MissingFail fails the run
+	MissingFail MissingPolicy = "fail"
+	// MissingStale reuses the last stored price, marked stale
+	MissingStale MissingPolicy = "stale"
+	// MissingDrop leaves the coin out of the report
+	MissingDrop MissingPolicy = "drop"
+)
+
 // CryptoService coordinates fetching, storing, and reporting crypto prices
 type CryptoService struct {
 	fetcher   domain.PriceFetcher
 	repo      domain.PriceRepository
 	generator domain.ReadmeGenerator
+	onMissing MissingPolicy
+	clock     domain.Clock
+}
+
+// Option configures a CryptoService
+type Option func(*CryptoService)
+
+// WithMissingPolicy sets how coins without a fetched price are handled; the default is MissingDrop
+func WithMissingPolicy(policy MissingPolicy) Option {
+	return func(s *CryptoService) {
+		s.onMissing = policy
+	}
+}
+
+// WithClock sets the clock reports are generated against; the default is the system clock
+func WithClock(clock domain.Clock) Option {
+	return func(s *CryptoService) {
+		s.clock = clock
+	}
 }
 
 // NewCryptoService creates a new crypto service
@@ -20,44 +54,144 @@ func NewCryptoService(
 	fetcher domain.PriceFetcher,
 	repo domain.PriceRepository,
 	generator domain.ReadmeGenerator,
+	opts ...Option,
 ) *CryptoService {
-	return &CryptoService{
+	s := &CryptoService{
 		fetcher:   fetcher,
 		repo:      repo,
 		generator: generator,
+		onMissing: MissingDrop,
+		clock:     domain.SystemClock,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // UpdateAndGenerateReport fetches latest prices, stores them, and generates a report
 func (s *CryptoService) UpdateAndGenerateReport(ctx context.Context, coins []domain.CoinMetadata) (string, []domain.CoinStats, error) {
-	coinIDs := make([]string, len(coins))
-	for i, c := range coins {
-		coinIDs[i] = c.ID
+	prices, err := s.UpdatePrices(ctx, coins)
+	if err != nil {
+		return "", nil, err
 	}
 
+	content, stats := s.GenerateReport(coins, prices)
+	return content, stats, nil
+}
+
+// UpdatePrices fetches latest prices and stores them without generating a report.
+// Coins the fetcher could not price are handled according to the missing policy:
+// dropped, or included in the result with a stale or missing status.
+func (s *CryptoService) UpdatePrices(ctx context.Context, coins []domain.CoinMetadata) (map[string]domain.CryptoPrice, error) {
 	log.Println("Fetching latest prices from API...")
-	prices, err := s.fetcher.FetchPrices(ctx, coinIDs)
-	if err != nil {
-		return "", nil, fmt.Errorf("failed to fetch prices: %w", err)
+	prices, err := s.fetcher.FetchPrices(ctx, coinIDs(coins))
+	// Only a PartialError means the returned prices are usable
+	var partial *domain.PartialError
+	isPartial := errors.As(err, &partial)
+	switch {
+	case isPartial && s.onMissing != MissingFail:
+		log.Printf("Warning: %v", partial)
+	case err != nil:
+		return nil, fmt.Errorf("failed to fetch prices: %w", err)
 	}
 	log.Printf("Successfully fetched prices for %d coins", len(prices))
 
 	log.Println("Saving prices to database...")
 	if err := s.repo.SavePrices(prices); err != nil {
-		return "", nil, fmt.Errorf("failed to save prices: %w", err)
+		return nil, fmt.Errorf("failed to save prices: %w", err)
 	}
 	log.Println("Prices saved successfully")
 
+	if isPartial && s.onMissing != MissingDrop {
+		if prices == nil {
+			prices = make(map[string]domain.CryptoPrice, len(partial.Missing))
+		}
+		if err := s.applyMissingPolicy(prices, partial); err != nil {
+			return nil, err
+		}
+	}
+
+	return prices, nil
+}
+
+// applyMissingPolicy adds an entry for every coin the fetch could not price: the
+// last stored price marked stale, or a missing marker so reports can say why.
+// It is not called for MissingDrop, which leaves those coins out.
+func (s *CryptoService) applyMissingPolicy(prices map[string]domain.CryptoPrice, partial *domain.PartialError) error {
+	for _, m := range partial.Missing {
+		reason := m.Err.Error()
+		if s.onMissing == MissingStale {
+			last, ok, err := s.repo.GetLatestPrice(m.Coin, s.clock.Now())
+			if err != nil {
+				return fmt.Errorf("failed to load last price for %s: %w", m.Coin, err)
+			}
+			if ok {
+				log.Printf("Using last stored price of %s from %s", m.Coin, last.FetchedAt.Format(time.DateTime))
+				last.Status = domain.StatusStale
+				last.StatusReason = reason
+				prices[m.Coin] = last
+				continue
+			}
+		}
+		prices[m.Coin] = domain.CryptoPrice{Coin: m.Coin, Status: domain.StatusMissing, StatusReason: reason}
+	}
+	return nil
+}
+
+// ReportFromHistory generates a report from the latest stored prices without calling the API
+func (s *CryptoService) ReportFromHistory(coins []domain.CoinMetadata) (string, []domain.CoinStats, error) {
+	prices, err := s.LatestPrices(coins)
+	if err != nil {
+		return "", nil, err
+	}
+
+	content, stats := s.GenerateReport(coins, prices)
+	return content, stats, nil
+}
+
+// LatestPrices loads the most recent price stored for each coin as of the service's clock
+func (s *CryptoService) LatestPrices(coins []domain.CoinMetadata) (map[string]domain.CryptoPrice, error) {
+	prices := make(map[string]domain.CryptoPrice, len(coins))
+	loaded := 0
+	now := s.clock.Now()
+	for _, coin := range coins {
+		price, ok, err := s.repo.GetLatestPrice(coin.ID, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load latest price for %s: %w", coin.ID, err)
+		}
+		if !ok {
+			prices[coin.ID] = domain.CryptoPrice{Coin: coin.ID, Status: domain.StatusMissing, StatusReason: "no stored price"}
+			continue
+		}
+		prices[coin.ID] = price
+		loaded++
+	}
+	log.Printf("Loaded stored prices for %d coins", loaded)
+	return prices, nil
+}
+
+// GenerateReport builds coin statistics from prices and renders the README
+func (s *CryptoService) GenerateReport(coins []domain.CoinMetadata, prices map[string]domain.CryptoPrice) (string, []domain.CoinStats) {
 	stats := s.buildStats(coins, prices)
 
 	log.Println("Generating README...")
 	content := s.generator.Generate(stats, coins)
 
-	return content, stats, nil
+	return content, stats
+}
+
+func coinIDs(coins []domain.CoinMetadata) []string {
+	ids := make([]string, len(coins))
+	for i, c := range coins {
+		ids[i] = c.ID
+	}
+	return ids
 }
 
 func (s *CryptoService) buildStats(coins []domain.CoinMetadata, prices map[string]domain.CryptoPrice) []domain.CoinStats {
 	stats := make([]domain.CoinStats, 0, len(coins))
+	now := s.clock.Now()
 
 	for _, coin := range coins {
 		price, ok := prices[coin.ID]
@@ -66,13 +200,38 @@ func (s *CryptoService) buildStats(coins []domain.CoinMetadata, prices map[strin
 			continue
 		}
 
+		status := price.Status
+		if status == "" {
+			status = domain.StatusOK
+		}
+		if status == domain.StatusMissing {
+			log.Printf("No price data for %s: %s", coin.ID, price.StatusReason)
+			stats = append(stats, domain.CoinStats{
+				Name:         coin.ID,
+				Symbol:       coin.Symbol,
+				Status:       status,
+				StatusReason: price.StatusReason,
+			})
+			continue
+		}
+
 		stat := domain.CoinStats{
-			Name:      coin.ID,
-			Symbol:    coin.Symbol,
-			Price:     price.PriceUSD,
-			Change24h: price.Change24h,
-			Change7d:  s.getHistoricalChange(coin.ID, price.PriceUSD, 7),
-			Change30d: s.getHistoricalChange(coin.ID, price.PriceUSD, 30),
+			Name:         coin.ID,
+			Symbol:       coin.Symbol,
+			Price:        price.PriceUSD,
+			Change24h:    price.Change24h,
+			HasChange24h: price.HasChange24h,
+			MarketCap:    price.MarketCap,
+			Volume24h:    price.Volume24h,
+			LastUpdated:  price.LastUpdated,
+			Source:       price.Source,
+			Sources:      price.Sources,
+			Spread:       price.Spread,
+			Change7d:     s.getHistoricalChange(coin.ID, price.PriceUSD, 7, now),
+			Change30d:    s.getHistoricalChange(coin.ID, price.PriceUSD, 30, now),
+			Quotes:       price.Quotes,
+			Status:       status,
+			StatusReason: price.StatusReason,
 		}
 
 		stats = append(stats, stat)
@@ -81,8 +240,8 @@ func (s *CryptoService) buildStats(coins []domain.CoinMetadata, prices map[strin
 	return stats
 }
 
-func (s *CryptoService) getHistoricalChange(coinID string, currentPrice float64, days int) domain.PriceChange {
-	pastPrice, hasData, err := s.repo.GetHistoricalPrice(coinID, days)
+func (s *CryptoService) getHistoricalChange(coinID string, currentPrice float64, days int, now time.Time) domain.PriceChange {
+	pastPrice, hasData, err := s.repo.GetHistoricalPrice(coinID, now.AddDate(0, 0, -days))
 	if err != nil {
 		log.Printf("Error getting %d-day history for %s: %v", days, coinID, err)
 		return domain.PriceChange{HasData: false, Days: days}
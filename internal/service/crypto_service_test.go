@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/viczuno/go-crypto-bot/internal/domain"
+	"github.com/viczuno/go-crypto-bot/internal/fake"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+var testCoins = []domain.CoinMetadata{
+	{ID: "bitcoin", Name: "Bitcoin", Symbol: "BTC"},
+	{ID: "ethereum", Name: "Ethereum", Symbol: "ETH"},
+}
+
+// testNow is the fixed time the tests run at
+var testNow = time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+
+func price(coin string, usd float64, at time.Time) domain.CryptoPrice {
+	return domain.CryptoPrice{Coin: coin, PriceUSD: usd, FetchedAt: at, Source: "coingecko"}
+}
+
+func TestUpdateAndGenerateReport(t *testing.T) {
+	now := testNow
+	errAPI := errors.New("api down")
+	errDB := errors.New("disk full")
+
+	tests := []struct {
+		name    string
+		prices  map[string]domain.CryptoPrice
+		fetch   error
+		save    error
+		policy  MissingPolicy
+		stored  []domain.CryptoPrice
+		wantErr error
+		// want maps each reported symbol to its status and price
+		want      map[string]domain.CoinStats
+		wantSaved int
+	}{
+		{
+			name: "all coins priced",
+			prices: map[string]domain.CryptoPrice{
+				"bitcoin":  price("bitcoin", 60000, now),
+				"ethereum": price("ethereum", 3000, now),
+			},
+			want: map[string]domain.CoinStats{
+				"BTC": {Status: domain.StatusOK, Price: 60000},
+				"ETH": {Status: domain.StatusOK, Price: 3000},
+			},
+			wantSaved: 2,
+		},
+		{
+			name:    "fetch error",
+			fetch:   errAPI,
+			wantErr: errAPI,
+		},
+		{
+			name:    "save error",
+			prices:  map[string]domain.CryptoPrice{"bitcoin": price("bitcoin", 60000, now), "ethereum": price("ethereum", 3000, now)},
+			save:    errDB,
+			wantErr: errDB,
+		},
+		{
+			name:   "missing coin dropped",
+			prices: map[string]domain.CryptoPrice{"bitcoin": price("bitcoin", 60000, now)},
+			policy: MissingDrop,
+			want: map[string]domain.CoinStats{
+				"BTC": {Status: domain.StatusOK, Price: 60000},
+			},
+			wantSaved: 1,
+		},
+		{
+			name:   "missing coin uses stale price",
+			prices: map[string]domain.CryptoPrice{"bitcoin": price("bitcoin", 60000, now)},
+			policy: MissingStale,
+			stored: []domain.CryptoPrice{price("ethereum", 2900, now.Add(-time.Hour))},
+			want: map[string]domain.CoinStats{
+				"BTC": {Status: domain.StatusOK, Price: 60000},
+				"ETH": {Status: domain.StatusStale, Price: 2900},
+			},
+			wantSaved: 1,
+		},
+		{
+			name:   "stale policy without stored price",
+			prices: map[string]domain.CryptoPrice{"bitcoin": price("bitcoin", 60000, now)},
+			policy: MissingStale,
+			want: map[string]domain.CoinStats{
+				"BTC": {Status: domain.StatusOK, Price: 60000},
+				"ETH": {Status: domain.StatusMissing},
+			},
+			wantSaved: 1,
+		},
+		{
+			name:    "missing coin fails the run",
+			prices:  map[string]domain.CryptoPrice{"bitcoin": price("bitcoin", 60000, now)},
+			policy:  MissingFail,
+			wantErr: domain.ErrNoData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &fake.Fetcher{Prices: tt.prices, Err: tt.fetch}
+			repo := fake.NewRepository(tt.stored...)
+			repo.SaveErr = tt.save
+			gen := &fake.Generator{}
+
+			opts := []Option{WithClock(domain.FixedClock(now))}
+			if tt.policy != "" {
+				opts = append(opts, WithMissingPolicy(tt.policy))
+			}
+			svc := NewCryptoService(fetcher, repo, gen, opts...)
+
+			content, stats, err := svc.UpdateAndGenerateReport(context.Background(), testCoins)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if len(gen.Calls) != 0 {
+					t.Errorf("generator called %d times after a failed update", len(gen.Calls))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(fetcher.Calls) != 1 || len(fetcher.Calls[0]) != len(testCoins) {
+				t.Errorf("fetch calls = %v, want one call for %d coins", fetcher.Calls, len(testCoins))
+			}
+			if len(repo.Saved) != 1 || len(repo.Saved[0]) != tt.wantSaved {
+				t.Errorf("saved = %v, want one save of %d prices", repo.Saved, tt.wantSaved)
+			}
+			if len(gen.Calls) != 1 {
+				t.Fatalf("generator called %d times, want 1", len(gen.Calls))
+			}
+			if content == "" {
+				t.Error("empty report")
+			}
+
+			if len(stats) != len(tt.want) {
+				t.Fatalf("got %d stats, want %d", len(stats), len(tt.want))
+			}
+			for _, s := range stats {
+				want, ok := tt.want[s.Symbol]
+				if !ok {
+					t.Errorf("unexpected stats for %s", s.Symbol)
+					continue
+				}
+				if s.Status != want.Status || s.Price != want.Price {
+					t.Errorf("%s: status %q price %v, want %q %v", s.Symbol, s.Status, s.Price, want.Status, want.Price)
+				}
+				if s.Status != domain.StatusOK && s.StatusReason == "" {
+					t.Errorf("%s: %s without a reason", s.Symbol, s.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestGetHistoricalChange(t *testing.T) {
+	now := testNow
+	errDB := errors.New("locked")
+
+	tests := []struct {
+		name    string
+		history []domain.CryptoPrice
+		err     error
+		current float64
+		days    int
+		want    domain.PriceChange
+	}{
+		{
+			name:    "no history",
+			current: 100,
+			days:    7,
+			want:    domain.PriceChange{Days: 7},
+		},
+		{
+			name:    "history too recent",
+			history: []domain.CryptoPrice{price("bitcoin", 80, now.Add(-3*24*time.Hour))},
+			current: 100,
+			days:    7,
+			want:    domain.PriceChange{Days: 7},
+		},
+		{
+			name:    "repository error",
+			history: []domain.CryptoPrice{price("bitcoin", 80, now.Add(-8*24*time.Hour))},
+			err:     errDB,
+			current: 100,
+			days:    7,
+			want:    domain.PriceChange{Days: 7},
+		},
+		{
+			name:    "gain",
+			history: []domain.CryptoPrice{price("bitcoin", 80, now.Add(-8*24*time.Hour))},
+			current: 100,
+			days:    7,
+			want:    domain.PriceChange{PastPrice: 80, CurrentPrice: 100, AbsChange: 20, PctChange: 25, HasData: true, Days: 7},
+		},
+		{
+			name: "uses the newest price before the cutoff",
+			history: []domain.CryptoPrice{
+				price("bitcoin", 50, now.Add(-40*24*time.Hour)),
+				price("bitcoin", 200, now.Add(-31*24*time.Hour)),
+				price("bitcoin", 90, now.Add(-24*time.Hour)),
+			},
+			current: 100,
+			days:    30,
+			want:    domain.PriceChange{PastPrice: 200, CurrentPrice: 100, AbsChange: -100, PctChange: -50, HasData: true, Days: 30},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := fake.NewRepository(tt.history...)
+			repo.HistoricalErr = tt.err
+			svc := NewCryptoService(&fake.Fetcher{}, repo, &fake.Generator{})
+
+			got := svc.getHistoricalChange("bitcoin", tt.current, tt.days, now)
+			if got.HasData != tt.want.HasData || got.Days != tt.want.Days ||
+				got.PastPrice != tt.want.PastPrice || got.CurrentPrice != tt.want.CurrentPrice ||
+				math.Abs(got.AbsChange-tt.want.AbsChange) > 1e-9 || math.Abs(got.PctChange-tt.want.PctChange) > 1e-9 {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportFromHistoryAsOf(t *testing.T) {
+	repo := fake.NewRepository(
+		price("bitcoin", 50000, testNow.AddDate(0, 0, -8)),
+		price("bitcoin", 60000, testNow.Add(-time.Hour)),
+		price("bitcoin", 70000, testNow.Add(time.Hour)),
+		price("ethereum", 3000, testNow.Add(time.Hour)),
+	)
+	svc := NewCryptoService(&fake.Fetcher{}, repo, &fake.Generator{}, WithClock(domain.FixedClock(testNow)))
+
+	_, stats, err := svc.ReportFromHistory(testCoins)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("got %d stats, want 2", len(stats))
+	}
+
+	btc, eth := stats[0], stats[1]
+	if btc.Price != 60000 || btc.Status != domain.StatusOK {
+		t.Errorf("BTC: price %v status %q, want the last price before the clock", btc.Price, btc.Status)
+	}
+	if !btc.Change7d.HasData || btc.Change7d.PastPrice != 50000 {
+		t.Errorf("BTC 7d change = %+v, want it against 50000", btc.Change7d)
+	}
+	if eth.Status != domain.StatusMissing {
+		t.Errorf("ETH status = %q, want %q for a coin first stored after the clock", eth.Status, domain.StatusMissing)
+	}
+}